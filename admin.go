@@ -0,0 +1,320 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+)
+
+const maxInviteLifetime = 90 * 24 * time.Hour
+
+func (self *apiConfig) middlewareAdmin(next authedHandler) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if user.Role != roleAdmin {
+			respondWithError(w, http.StatusForbidden, "Only admins can do this")
+			return
+		}
+		next(w, r, user)
+	}
+}
+
+// Removes a user with everything that belongs to them. Feeds they added stay
+// for other followers and planets and are only dropped once nobody follows
+// them, posts kept only for the user's stars go too.
+func (self *apiConfig) deleteUser(r *http.Request, actor uuid.UUID, userID uuid.UUID) (int64, error) {
+	var deleted int64
+	var feeds []database.DeleteUnfollowedOrphanFeedsRow
+	err := self.inTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		deleted, err = q.DeleteUser(r.Context(), userID)
+		if err != nil || deleted == 0 {
+			return err
+		}
+		feeds, err = q.DeleteUnfollowedOrphanFeeds(r.Context())
+		if err != nil {
+			return err
+		}
+		// Their stars went with them, posts of removed feeds may have no others
+		return q.DeleteUnstarredOrphanPosts(r.Context())
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, feed := range feeds {
+		self.audit(r, actor, auditEvent{
+			Action:     auditFeedDelete,
+			TargetType: "feed",
+			TargetID:   feed.ID,
+			Subject:    userID,
+			Details:    map[string]any{"name": feed.Name, "url": feed.Url},
+		})
+	}
+	return deleted, nil
+}
+
+func (self *apiConfig) getAdminUsers(w http.ResponseWriter, r *http.Request, user database.User) {
+	users, err := self.DB.GetAllUsers(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get users")
+		return
+	}
+
+	jsonUsers := make([]database.JSONUser, len(users))
+	for i := 0; i < len(users); i++ {
+		jsonUsers[i] = users[i].Json()
+	}
+	respondWithJSON(w, http.StatusOK, jsonUsers)
+}
+
+// Changes the role of a user or disables their account. Admins cannot
+// change their own account so an instance always keeps an admin.
+func (self *apiConfig) putAdminUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Role     *string `json:"role"`
+		Disabled *bool   `json:"disabled"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithInvalidID(w, "user")
+		return
+	}
+	if userID == user.ID {
+		respondWithError(w, http.StatusConflict, "Admins cannot change their own account")
+		return
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+
+	target, err := self.DB.GetUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update user")
+		return
+	}
+
+	now := time.Now().UTC()
+	if params.Role != nil {
+		if *params.Role != roleUser && *params.Role != roleAdmin {
+			respondWithFieldError(w, "role", "Role must be user or admin")
+			return
+		}
+		target.Role = *params.Role
+	}
+	if params.Disabled != nil {
+		if !*params.Disabled {
+			target.DisabledAt = sql.NullTime{}
+		} else if !target.DisabledAt.Valid {
+			target.DisabledAt = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+
+	updated, err := self.DB.UpdateUserAdmin(r.Context(), database.UpdateUserAdminParams{
+		Role:       target.Role,
+		DisabledAt: target.DisabledAt,
+		UpdatedAt:  now,
+		ID:         target.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update user")
+		return
+	}
+	// Disabled accounts are refused by middlewareAuth, their sessions can go
+	if updated.DisabledAt.Valid {
+		if err := self.DB.DeleteUserSessions(r.Context(), updated.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not end user sessions")
+			return
+		}
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditAdminUserUpdate,
+		TargetType: "user",
+		TargetID:   updated.ID,
+		Subject:    updated.ID,
+		Details:    map[string]any{"role": updated.Role, "disabled": updated.DisabledAt.Valid},
+	})
+	respondWithJSON(w, http.StatusOK, updated.Json())
+}
+
+func (self *apiConfig) deleteAdminUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithInvalidID(w, "user")
+		return
+	}
+	if userID == user.ID {
+		respondWithError(w, http.StatusConflict, "Admins cannot delete their own account")
+		return
+	}
+
+	deleted, err := self.deleteUser(r, user.ID, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete user")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditAdminUserDelete,
+		TargetType: "user",
+		TargetID:   userID,
+		Subject:    userID,
+	})
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+// Revokes every key and session of a user and issues a single new key for
+// the admin to hand over. The admin never acts as the user.
+func (self *apiConfig) postAdminResetApiKeys(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		database.JSONApiKey
+		Key string `json:"key"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithInvalidID(w, "user")
+		return
+	}
+	target, err := self.DB.GetUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not reset API keys")
+		return
+	}
+
+	if err := self.DB.RevokeUserApiKeys(r.Context(), database.RevokeUserApiKeysParams{
+		RevokedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		UserID:    target.ID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not reset API keys")
+		return
+	}
+	if err := self.DB.DeleteUserSessions(r.Context(), target.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not reset API keys")
+		return
+	}
+	if _, err := self.DB.DeleteFeedToken(r.Context(), target.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not reset API keys")
+		return
+	}
+
+	key, apiKey, err := createApiKey(r.Context(), self.DB, target, "default", []string{scopeAdmin}, uuid.NullUUID{})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create API key")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditAdminKeysReset,
+		TargetType: "user",
+		TargetID:   target.ID,
+		Subject:    target.ID,
+		Details:    map[string]any{"new_key_id": apiKey.ID},
+	})
+	respondWithJSON(w, http.StatusOK, response{apiKey.Json(), key})
+}
+
+func (self *apiConfig) postCreateInviteCode(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		// Seconds until the code expires, codes without one never expire
+		ExpiresIn int64 `json:"expires_in"`
+	}
+
+	params := parameters{}
+	if r.ContentLength != 0 && !decodeBody(w, r, &params) {
+		return
+	}
+	expiresIn := time.Duration(params.ExpiresIn) * time.Second
+	if expiresIn < 0 || expiresIn > maxInviteLifetime {
+		respondWithFieldError(w, "expires_in", "Must be between 0 and 7776000 seconds")
+		return
+	}
+
+	code, err := generateToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create invite code")
+		return
+	}
+	now := time.Now().UTC()
+	expiresAt := sql.NullTime{}
+	if expiresIn > 0 {
+		expiresAt = sql.NullTime{Time: now.Add(expiresIn), Valid: true}
+	}
+
+	invite, err := self.DB.CreateInviteCode(r.Context(), database.CreateInviteCodeParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		CreatedBy: uuid.NullUUID{UUID: user.ID, Valid: true},
+		Code:      code[:20],
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create invite code")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditInviteCreate,
+		TargetType: "invite_code",
+		TargetID:   invite.ID,
+	})
+	respondWithJSON(w, http.StatusOK, invite.Json())
+}
+
+func (self *apiConfig) getInviteCodes(w http.ResponseWriter, r *http.Request, user database.User) {
+	invites, err := self.DB.GetInviteCodes(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get invite codes")
+		return
+	}
+
+	jsonInvites := make([]database.JSONInviteCode, len(invites))
+	for i := 0; i < len(invites); i++ {
+		jsonInvites[i] = invites[i].Json()
+	}
+	respondWithJSON(w, http.StatusOK, jsonInvites)
+}
+
+func (self *apiConfig) deleteInviteCode(w http.ResponseWriter, r *http.Request, user database.User) {
+	inviteID, err := uuid.Parse(r.PathValue("inviteID"))
+	if err != nil {
+		respondWithInvalidID(w, "invite code")
+		return
+	}
+
+	deleted, err := self.DB.DeleteInviteCode(r.Context(), inviteID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete invite code")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Unused invite code not found")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditInviteDelete,
+		TargetType: "invite_code",
+		TargetID:   inviteID,
+	})
+	respondWithJSON(w, http.StatusOK, "")
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -15,73 +18,268 @@ import (
 
 type apiConfig struct {
 	DB *database.Queries
+	// The connection pool behind DB, for transactions
+	Conn *sql.DB
+	// Registration needs an invite code from an admin
+	InviteOnly bool
+	// Limits of each group of routes, defaultRateLimits for those missing
+	RateLimits map[string]rateLimit
+}
+
+// Runs fn in a transaction, committed when it returns nil
+func (self *apiConfig) inTx(ctx context.Context, fn func(*database.Queries) error) error {
+	tx, err := self.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(self.DB.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 type authedHandler func(http.ResponseWriter, *http.Request, database.User)
 
+type contextKey string
+
+// Set by middlewareAuth to the ID of the API key used for the request
+const apiKeyIDContextKey contextKey = "apiKeyID"
+
 func respondWithError(w http.ResponseWriter, code int, msg string) {
-	type errorResponse struct {
-		Error string `json:"error"`
-	}
-	data, _ := json.Marshal(errorResponse{msg})
-	log.Printf("Error: %s", msg)
-	w.WriteHeader(code)
-	w.Write(data)
+	respondWithCode(w, code, codeForStatus(code), msg)
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	data, err := json.Marshal(payload)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		log.Printf("Error encoding response: %v", err.Error())
+		respondWithError(w, http.StatusInternalServerError, "Could not encode response")
+		return
 	}
 	log.Println("Successful response")
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	w.Write(data)
 }
 
 func (self *apiConfig) postCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Name string `json:"name"`
+		Name       string `json:"name"`
+		InviteCode string `json:"invite_code"`
+	}
+	type response struct {
+		database.JSONUser
+		ApiKey string `json:"api_key"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	if err := decoder.Decode(&params); err != nil {
-		respondWithError(w, 500, err.Error())
+	if !decodeBody(w, r, &params) {
 		return
 	}
-	user, err := self.DB.CreateUser(r.Context(), database.CreateUserParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Name:      params.Name,
-	})
+	params.Name = strings.TrimSpace(params.Name)
+	v := validator{}
+	v.required("name", params.Name)
+	v.maxLength("name", params.Name, maxNameLength)
+	v.maxLength("invite_code", params.InviteCode, maxInviteLength)
+	if v.failed(w) {
+		return
+	}
+
+	// Registrations are serialized so only the very first user becomes the
+	// admin, and an invite is only used up once the user and key exist
+	var user database.User
+	var invite database.InviteCode
+	var key string
+	var apiKey database.ApiKey
+	err := self.inTx(r.Context(), func(q *database.Queries) error {
+		if err := q.LockUsers(r.Context()); err != nil {
+			return err
+		}
+		// The first user administers the instance and needs no invite
+		userCount, err := q.CountUsers(r.Context())
+		if err != nil {
+			return err
+		}
+		role := roleUser
+		if userCount == 0 {
+			role = roleAdmin
+		}
+
+		if self.InviteOnly && role != roleAdmin {
+			invite, err = q.RedeemInviteCode(r.Context(), database.RedeemInviteCodeParams{
+				Code: params.InviteCode,
+				Now:  time.Now().UTC(),
+			})
+			if err != nil {
+				return err
+			}
+		}
 
+		user, err = q.CreateUser(r.Context(), database.CreateUserParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name:      params.Name,
+			Role:      role,
+		})
+		if err != nil {
+			return err
+		}
+		if invite.ID != uuid.Nil {
+			if err := q.SetInviteCodeUser(r.Context(), database.SetInviteCodeUserParams{
+				UsedBy: uuid.NullUUID{UUID: user.ID, Valid: true},
+				ID:     invite.ID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		key, apiKey, err = createApiKey(r.Context(), q, user, "default", []string{scopeAdmin}, uuid.NullUUID{})
+		return err
+	})
+	// Only redeeming the invite finds no row
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusForbidden, "Registration needs a valid invite code")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Could not create user")
 		return
 	}
-	respondWithJSON(w, http.StatusOK, user)
+
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditUserCreate,
+		TargetType: "user",
+		TargetID:   user.ID,
+		Details:    map[string]any{"name": user.Name, "role": user.Role, "invited": invite.ID != uuid.Nil},
+	})
+
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditApiKeyCreate,
+		TargetType: "api_key",
+		TargetID:   apiKey.ID,
+		Details:    map[string]any{"name": apiKey.Name, "scopes": apiKey.Scopes},
+	})
+	respondWithJSON(w, http.StatusOK, response{user.Json(), key})
 }
 
-func (self *apiConfig) middlewareAuth(next authedHandler) http.HandlerFunc {
+// Authenticates the request by API key or session cookie. An API key must
+// carry scope unless it is empty, sessions have every scope.
+func (self *apiConfig) middlewareAuth(scope string, next authedHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authStr := r.Header.Get("Authorization")
 		if !strings.HasPrefix(authStr, "ApiKey ") {
-			respondWithError(w, http.StatusUnauthorized, "API key not provided")
+			if cookie, err := r.Cookie(sessionCookie); err == nil {
+				self.authenticateSession(w, r, cookie.Value, next)
+				return
+			}
+			respondWithError(w, http.StatusUnauthorized, "API key or session not provided")
 			return
 		}
-		user, err := self.DB.GetUser(r.Context(), authStr[7:])
+		now := time.Now().UTC()
+		row, err := self.DB.GetUserByApiKey(r.Context(), database.GetUserByApiKeyParams{
+			KeyHash: hashToken(authStr[7:]),
+			Now:     now,
+		})
 		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, err.Error())
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired API key")
+			return
+		}
+		if !hasScope(row.Scopes, scope) {
+			respondWithError(w, http.StatusForbidden, fmt.Sprintf("API key lacks the %s scope", scope))
 			return
 		}
-		next(w, r, user)
+
+		// Last use is only tracked to the minute to spare a write per request
+		if !row.LastUsedAt.Valid || now.Sub(row.LastUsedAt.Time) > time.Minute {
+			if err := self.DB.TouchApiKey(r.Context(), database.TouchApiKeyParams{
+				LastUsedAt: sql.NullTime{Time: now, Valid: true},
+				ID:         row.ApiKeyID,
+			}); err != nil {
+				log.Printf("Error updating API key last use: %v", err.Error())
+			}
+		}
+		ctx := context.WithValue(r.Context(), apiKeyIDContextKey, row.ApiKeyID)
+		next(w, r.WithContext(ctx), row.User)
 	}
 }
 
 func (self *apiConfig) getCurrentUser(w http.ResponseWriter, r *http.Request, user database.User) {
-	respondWithJSON(w, http.StatusOK, user)
+	respondWithJSON(w, http.StatusOK, user.Json())
+}
+
+func (self *apiConfig) patchCurrentUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name *string `json:"name"`
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	if params.Name == nil {
+		respondWithJSON(w, http.StatusOK, user.Json())
+		return
+	}
+	name := strings.TrimSpace(*params.Name)
+	v := validator{}
+	v.required("name", name)
+	v.maxLength("name", name, maxNameLength)
+	if v.failed(w) {
+		return
+	}
+
+	updated, err := self.DB.UpdateUserName(r.Context(), database.UpdateUserNameParams{
+		Name:      name,
+		UpdatedAt: time.Now().UTC(),
+		ID:        user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update user")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditUserUpdate,
+		TargetType: "user",
+		TargetID:   user.ID,
+		Details:    map[string]any{"old_name": user.Name, "name": updated.Name},
+	})
+	respondWithJSON(w, http.StatusOK, updated.Json())
+}
+
+// Deletes the account along with its follows, folders, read states, keys and
+// sessions. Starred posts and feeds others follow are left alone.
+func (self *apiConfig) deleteCurrentUser(w http.ResponseWriter, r *http.Request, user database.User) {
+	if user.Role == roleAdmin {
+		otherAdmins, err := self.DB.CountOtherActiveAdmins(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not delete user")
+			return
+		}
+		userCount, err := self.DB.CountUsers(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not delete user")
+			return
+		}
+		if otherAdmins == 0 && userCount > 1 {
+			respondWithError(w, http.StatusConflict, "Make another user admin before deleting the last admin")
+			return
+		}
+	}
+
+	if _, err := self.deleteUser(r, user.ID, user.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete user")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditUserDelete,
+		TargetType: "user",
+		TargetID:   user.ID,
+		Details:    map[string]any{"name": user.Name},
+	})
+	setSessionCookies(w, r, "", "", time.Time{})
+	respondWithJSON(w, http.StatusOK, "")
 }
 
 func (self *apiConfig) postCreateFeed(w http.ResponseWriter, r *http.Request, user database.User) {
@@ -90,14 +288,22 @@ func (self *apiConfig) postCreateFeed(w http.ResponseWriter, r *http.Request, us
 		Url  string `json:"url"`
 	}
 	type response struct {
-		Feed       database.JSONFeed   `json:"feed"`
-		FeedFollow database.FeedFollow `json:"feed_follow"`
+		Feed       database.JSONFeed       `json:"feed"`
+		FeedFollow database.JSONFeedFollow `json:"feed_follow"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	if err := decoder.Decode(&params); err != nil {
-		respondWithError(w, 500, err.Error())
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	params.Url = strings.TrimSpace(params.Url)
+	v := validator{}
+	v.required("name", params.Name)
+	v.maxLength("name", params.Name, maxNameLength)
+	v.required("url", params.Url)
+	v.url("url", params.Url)
+	if v.failed(w) {
 		return
 	}
 
@@ -107,8 +313,12 @@ func (self *apiConfig) postCreateFeed(w http.ResponseWriter, r *http.Request, us
 		UpdatedAt: time.Now().UTC(),
 		Name:      params.Name,
 		Url:       params.Url,
-		UserID:    user.ID,
+		UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "A feed with this URL already exists")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Could not create feed")
 		return
@@ -121,14 +331,30 @@ func (self *apiConfig) postCreateFeed(w http.ResponseWriter, r *http.Request, us
 		FeedID:    feed.ID,
 		UserID:    user.ID,
 	})
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditFeedCreate,
+		TargetType: "feed",
+		TargetID:   feed.ID,
+		Details:    map[string]any{"name": feed.Name, "url": feed.Url},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not follow the new feed")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditFollowCreate,
+		TargetType: "feed_follow",
+		TargetID:   feedFollow.ID,
+		Details:    map[string]any{"feed_id": feed.ID},
+	})
 
-	respondWithJSON(w, http.StatusOK, response{feed.Json(), feedFollow})
+	respondWithJSON(w, http.StatusOK, response{feed.Json(), feedFollow.Json()})
 }
 
 func (self *apiConfig) getAllFeeds(w http.ResponseWriter, r *http.Request) {
 	feeds, err := self.DB.GetAllFeeds(r.Context())
 	if err != nil {
-		respondWithError(w, http.StatusServiceUnavailable, "Unable to get the feeds")
+		respondWithError(w, http.StatusInternalServerError, "Unable to get the feeds")
 		return
 	}
 
@@ -142,13 +368,25 @@ func (self *apiConfig) getAllFeeds(w http.ResponseWriter, r *http.Request) {
 
 func (self *apiConfig) postCreateFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
 	type parameters struct {
-		FeedID uuid.UUID `json:"feed_id"`
+		FeedID   string  `json:"feed_id"`
+		Title    *string `json:"title"`
+		FolderID *string `json:"folder_id"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	if err := decoder.Decode(&params); err != nil {
-		respondWithError(w, 500, err.Error())
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	v := validator{}
+	v.required("feed_id", params.FeedID)
+	feedID := v.id("feed_id", params.FeedID)
+	if params.Title != nil {
+		v.required("title", *params.Title)
+		v.maxLength("title", *params.Title, maxNameLength)
+	}
+	folderID, err := self.userFolderID(r.Context(), user, v.optionalID("folder_id", params.FolderID))
+	v.check(err == nil, "folder_id", "Folder not found")
+	if v.failed(w) {
 		return
 	}
 
@@ -156,71 +394,327 @@ func (self *apiConfig) postCreateFeedFollow(w http.ResponseWriter, r *http.Reque
 		ID:        uuid.New(),
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
-		FeedID:    params.FeedID,
+		FeedID:    feedID,
 		UserID:    user.ID,
+		Title:     nullString(params.Title),
+		FolderID:  folderID,
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Already following this feed")
+		return
+	}
+	if isForeignKeyViolation(err) {
+		respondWithFieldError(w, "feed_id", "Feed not found")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Could not create feed follow")
 		return
 	}
-	respondWithJSON(w, http.StatusOK, feedFollow)
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditFollowCreate,
+		TargetType: "feed_follow",
+		TargetID:   feedFollow.ID,
+		Details:    map[string]any{"feed_id": feedFollow.FeedID},
+	})
+	respondWithJSON(w, http.StatusOK, feedFollow.Json())
+}
+
+type feedFollowWithUnread struct {
+	database.JSONFeedFollow
+	UnreadCount int64 `json:"unread_count"`
 }
 
 func (self *apiConfig) getUserFeedFollows(w http.ResponseWriter, r *http.Request, user database.User) {
-	feefFollows, err := self.DB.GetUserFeedFollows(r.Context(), user.ID)
+	folderID, err := folderFromQuery(r)
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	var feefFollows []database.FeedFollow
+	if folderID.Valid {
+		feefFollows, err = self.DB.GetUserFeedFollowsInFolder(r.Context(), database.GetUserFeedFollowsInFolderParams{
+			UserID:   user.ID,
+			FolderID: folderID,
+		})
+	} else {
+		feefFollows, err = self.DB.GetUserFeedFollows(r.Context(), user.ID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get feed follows")
+		return
+	}
+
+	unreadCounts, err := self.DB.GetUserUnreadCounts(r.Context(), user.ID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, http.StatusInternalServerError, "Could not get unread counts")
 		return
 	}
-	respondWithJSON(w, http.StatusOK, feefFollows)
+	unread := make(map[uuid.UUID]int64, len(unreadCounts))
+	for _, count := range unreadCounts {
+		unread[count.FeedID.UUID] = count.Unread
+	}
+
+	jsonFeedFollows := make([]feedFollowWithUnread, len(feefFollows))
+	for i := 0; i < len(feefFollows); i++ {
+		jsonFeedFollows[i] = feedFollowWithUnread{
+			JSONFeedFollow: feefFollows[i].Json(),
+			UnreadCount:    unread[feefFollows[i].FeedID],
+		}
+	}
+
+	if r.URL.Query().Get("group_by") != "folder" {
+		respondWithJSON(w, http.StatusOK, jsonFeedFollows)
+		return
+	}
+
+	folders, err := self.DB.GetUserFolders(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get folders")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, groupByFolder(folders, jsonFeedFollows,
+		func(ff feedFollowWithUnread) uuid.NullUUID { return ff.FolderID },
+	))
+}
+
+// Replaces the user's custom title and folder of a feed follow
+func (self *apiConfig) putFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Title    *string `json:"title"`
+		FolderID *string `json:"folder_id"`
+	}
+
+	feedFollowID, err := uuid.Parse(r.PathValue("ffID"))
+	if err != nil {
+		respondWithInvalidID(w, "feed follow")
+		return
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	v := validator{}
+	if params.Title != nil {
+		v.required("title", *params.Title)
+		v.maxLength("title", *params.Title, maxNameLength)
+	}
+	folderID, err := self.userFolderID(r.Context(), user, v.optionalID("folder_id", params.FolderID))
+	v.check(err == nil, "folder_id", "Folder not found")
+	if v.failed(w) {
+		return
+	}
+
+	feedFollow, err := self.DB.UpdateFeedFollow(r.Context(), database.UpdateFeedFollowParams{
+		Title:     nullString(params.Title),
+		FolderID:  folderID,
+		UpdatedAt: time.Now().UTC(),
+		ID:        feedFollowID,
+		UserID:    user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Feed follow not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update feed follow")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, feedFollow.Json())
+}
+
+// Toggles fetching the full article of new posts of the followed feed
+func (self *apiConfig) putFeedFollowFullText(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	feedFollowID, err := uuid.Parse(r.PathValue("ffID"))
+	if err != nil {
+		respondWithInvalidID(w, "feed follow")
+		return
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+
+	feedFollow, err := self.DB.SetFeedFollowFullText(r.Context(), database.SetFeedFollowFullTextParams{
+		FetchFullText: params.Enabled,
+		UpdatedAt:     time.Now().UTC(),
+		ID:            feedFollowID,
+		UserID:        user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Feed follow not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update feed follow")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, feedFollow.Json())
 }
 
 func (self *apiConfig) deleteFeedFollow(w http.ResponseWriter, r *http.Request, user database.User) {
 	ffID := r.PathValue("ffID")
 	feedFollowID, err := uuid.Parse(ffID)
 	if err != nil {
-		respondWithError(w, http.StatusServiceUnavailable, err.Error())
+		respondWithInvalidID(w, "feed follow")
+		return
 	}
 
 	feefFollows, err := self.DB.GetUserFeedFollows(r.Context(), user.ID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, http.StatusInternalServerError, "Could not get feed follows")
 		return
 	}
 
 	isUserFF := false
+	var feedID uuid.UUID
 	for _, ff := range feefFollows {
 		if ff.ID == feedFollowID {
 			isUserFF = true
+			feedID = ff.FeedID
 			break
 		}
 	}
 
 	if !isUserFF {
-		respondWithError(w, http.StatusUnauthorized, "Deletion not allowed")
+		respondWithError(w, http.StatusNotFound, "Feed follow not found")
 		return
 	}
 
 	if err = self.DB.DeleteFeedFollow(r.Context(), feedFollowID); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Could not create feed follow")
+		respondWithError(w, http.StatusInternalServerError, "Could not delete feed follow")
 		return
 	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditFollowDelete,
+		TargetType: "feed_follow",
+		TargetID:   feedFollowID,
+		Details:    map[string]any{"feed_id": feedID},
+	})
 	respondWithJSON(w, http.StatusOK, "")
 }
 
 func (self *apiConfig) getPostsForUser(w http.ResponseWriter, r *http.Request, user database.User) {
-	limitStr := r.URL.Query().Get("limit")
-	limit, err := strconv.Atoi(limitStr)
+	type response struct {
+		Posts      interface{} `json:"posts"`
+		NextCursor *string     `json:"next_cursor"`
+	}
+
+	limit, err := limitFromQuery(r)
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	folderID, err := folderFromQuery(r)
 	if err != nil {
-		limit = 5
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	before, err := timeFromQuery(r, "before")
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	after, err := timeFromQuery(r, "after")
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	feedIDs, err := uuidsFromQuery(r, "feed_id")
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	read, err := boolFromQuery(r, "read")
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	if r.URL.Query().Get("unread") == "true" {
+		read = sql.NullBool{Bool: false, Valid: true}
+	}
+	starred, err := boolFromQuery(r, "starred")
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
 	}
 
-	posts, err := self.DB.GetPostByUser(r.Context(), database.GetPostByUserParams{
-		UserID: user.ID,
-		Limit:  int32(limit),
+	var cursor *database.PostsCursor
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		if cursor, err = decodeCursor(cursorStr); err != nil {
+			respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+			return
+		}
+	}
+
+	// One extra post tells whether there is a next page
+	posts, err := self.DB.GetPostsTimeline(r.Context(), database.GetPostsTimelineParams{
+		UserID:     user.ID,
+		FeedIDs:    feedIDs,
+		FolderID:   folderID,
+		Author:     r.URL.Query().Get("author"),
+		Categories: r.URL.Query()["category"],
+		Read:       read,
+		Starred:    starred,
+		Before:     before,
+		After:      after,
+		Cursor:     cursor,
+		Limit:      limit + 1,
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Could not get posts for user")
+		return
+	}
+
+	var nextCursor *string
+	if len(posts) > int(limit) {
+		posts = posts[:limit]
+		next := encodeCursor(posts[limit-1])
+		nextCursor = &next
+	}
+
+	jsonPosts, err := self.postsJson(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get posts for user")
+		return
+	}
+
+	if r.URL.Query().Get("group_by") != "folder" {
+		respondWithJSON(w, http.StatusOK, response{jsonPosts, nextCursor})
+		return
+	}
+
+	folders, err := self.DB.GetUserFolders(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get folders")
+		return
+	}
+	feedFollows, err := self.DB.GetUserFeedFollows(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get feed follows")
+		return
+	}
+	feedFolders := make(map[uuid.UUID]uuid.NullUUID, len(feedFollows))
+	for _, ff := range feedFollows {
+		feedFolders[ff.FeedID] = ff.FolderID
+	}
+	groups := groupByFolder(folders, jsonPosts,
+		func(p database.JSONPost) uuid.NullUUID { return feedFolders[p.FeedID.UUID] },
+	)
+	respondWithJSON(w, http.StatusOK, response{groups, nextCursor})
+}
+
+func nullString(str *string) sql.NullString {
+	if str == nil {
+		return sql.NullString{}
 	}
-	respondWithJSON(w, http.StatusOK, posts)
+	return sql.NullString{String: *str, Valid: true}
 }
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+const maxGracePeriod = 7 * 24 * time.Hour
+
+// Scopes a route can require from the API key used to call it
+const (
+	scopePostsRead    = "posts:read"
+	scopePostsWrite   = "posts:write"
+	scopeFeedsWrite   = "feeds:write"
+	scopeFollowsRead  = "follows:read"
+	scopeFollowsWrite = "follows:write"
+	// Grants every other scope and is needed to manage keys
+	scopeAdmin = "admin"
+)
+
+var apiKeyScopes = []string{
+	scopePostsRead,
+	scopePostsWrite,
+	scopeFeedsWrite,
+	scopeFollowsRead,
+	scopeFollowsWrite,
+	scopeAdmin,
+}
+
+func hasScope(scopes []string, scope string) bool {
+	return scope == "" || slices.Contains(scopes, scope) || slices.Contains(scopes, scopeAdmin)
+}
+
+// Checks requested scopes, keys get full access when none are given
+func validScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return []string{scopeAdmin}, nil
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return nil, fmt.Errorf("Unknown scope %q, expected one of %s", scope, strings.Join(apiKeyScopes, ", "))
+		}
+	}
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
+
+// API keys and session tokens are only shown once, the database stores their
+// SHA-256. Tokens are random so a fast hash is enough.
+func hashToken(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateToken() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// Returns the new key in plain text along with its stored record. Takes the
+// queries to use so the key can be created within a transaction.
+func createApiKey(ctx context.Context, db *database.Queries, user database.User, name string, scopes []string, rotatedFrom uuid.NullUUID) (string, database.ApiKey, error) {
+	key, err := generateToken()
+	if err != nil {
+		return "", database.ApiKey{}, err
+	}
+	apiKey, err := db.CreateApiKey(ctx, database.CreateApiKeyParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+		UserID:      user.ID,
+		Name:        name,
+		Prefix:      key[:8],
+		KeyHash:     hashToken(key),
+		RotatedFrom: rotatedFrom,
+		Scopes:      scopes,
+	})
+	return key, apiKey, err
+}
+
+func (self *apiConfig) postCreateApiKey(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	type response struct {
+		database.JSONApiKey
+		Key string `json:"key"`
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	v := validator{}
+	v.required("name", params.Name)
+	v.maxLength("name", params.Name, maxNameLength)
+	scopes, err := validScopes(params.Scopes)
+	if err != nil {
+		v.check(false, "scopes", err.Error())
+	}
+	if v.failed(w) {
+		return
+	}
+
+	key, apiKey, err := createApiKey(r.Context(), self.DB, user, params.Name, scopes, uuid.NullUUID{})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create API key")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditApiKeyCreate,
+		TargetType: "api_key",
+		TargetID:   apiKey.ID,
+		Details:    map[string]any{"name": apiKey.Name, "scopes": apiKey.Scopes},
+	})
+	respondWithJSON(w, http.StatusOK, response{apiKey.Json(), key})
+}
+
+func (self *apiConfig) getUserApiKeys(w http.ResponseWriter, r *http.Request, user database.User) {
+	apiKeys, err := self.DB.GetUserApiKeys(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get API keys")
+		return
+	}
+
+	jsonApiKeys := make([]database.JSONApiKey, len(apiKeys))
+	for i := 0; i < len(apiKeys); i++ {
+		jsonApiKeys[i] = apiKeys[i].Json()
+	}
+	respondWithJSON(w, http.StatusOK, jsonApiKeys)
+}
+
+func (self *apiConfig) deleteApiKey(w http.ResponseWriter, r *http.Request, user database.User) {
+	keyID, err := uuid.Parse(r.PathValue("keyID"))
+	if err != nil {
+		respondWithInvalidID(w, "API key")
+		return
+	}
+
+	revoked, err := self.DB.RevokeApiKey(r.Context(), database.RevokeApiKeyParams{
+		RevokedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:        keyID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not revoke API key")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditApiKeyRevoke,
+		TargetType: "api_key",
+		TargetID:   keyID,
+	})
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+// Replaces an API key, by default the one used for the request, with a new
+// one of the same name and scopes. The old key stays valid during the grace period.
+func (self *apiConfig) postRotateApiKey(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		KeyID       *uuid.UUID `json:"key_id"`
+		GracePeriod int64      `json:"grace_period"`
+	}
+	type response struct {
+		ApiKey  database.JSONApiKey `json:"api_key"`
+		Key     string              `json:"key"`
+		Rotated database.JSONApiKey `json:"rotated"`
+	}
+
+	params := parameters{}
+	if r.ContentLength != 0 && !decodeBody(w, r, &params) {
+		return
+	}
+	gracePeriod := time.Duration(params.GracePeriod) * time.Second
+	if gracePeriod < 0 || gracePeriod > maxGracePeriod {
+		respondWithFieldError(w, "grace_period", "Must be between 0 and 604800 seconds")
+		return
+	}
+
+	keyID, ok := r.Context().Value(apiKeyIDContextKey).(uuid.UUID)
+	if params.KeyID != nil {
+		keyID = *params.KeyID
+	} else if !ok {
+		respondWithFieldError(w, "key_id", "Required when not authenticated by API key")
+		return
+	}
+
+	oldKey, err := self.DB.GetUserApiKey(r.Context(), database.GetUserApiKeyParams{
+		ID:     keyID,
+		UserID: user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not rotate API key")
+		return
+	}
+	now := time.Now().UTC()
+	if oldKey.RevokedAt.Valid || oldKey.RotatedAt.Valid || (oldKey.ExpiresAt.Valid && !oldKey.ExpiresAt.Time.After(now)) {
+		respondWithError(w, http.StatusConflict, "API key is no longer active")
+		return
+	}
+
+	// The old key is rotated first so a concurrent rotation finds it taken
+	// and creates no second replacement
+	var key string
+	var newKey database.ApiKey
+	err = self.inTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		oldKey, err = q.RotateApiKey(r.Context(), database.RotateApiKeyParams{
+			ExpiresAt: sql.NullTime{Time: now.Add(gracePeriod), Valid: true},
+			RotatedAt: sql.NullTime{Time: now, Valid: true},
+			ID:        oldKey.ID,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		key, newKey, err = createApiKey(r.Context(), q, user, oldKey.Name, oldKey.Scopes, uuid.NullUUID{UUID: oldKey.ID, Valid: true})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "API key is no longer active")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not rotate API key")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditApiKeyRotate,
+		TargetType: "api_key",
+		TargetID:   oldKey.ID,
+		Details:    map[string]any{"new_key_id": newKey.ID, "grace_period": params.GracePeriod},
+	})
+	respondWithJSON(w, http.StatusOK, response{newKey.Json(), key, oldKey.Json()})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+// Actions recorded in the audit log
+const (
+	auditUserCreate       = "user.create"
+	auditUserUpdate       = "user.update"
+	auditUserDelete       = "user.delete"
+	auditPasswordSet      = "user.password_set"
+	auditLogin            = "session.login"
+	auditLoginFailed      = "session.login_failed"
+	auditApiKeyCreate     = "api_key.create"
+	auditApiKeyRotate     = "api_key.rotate"
+	auditApiKeyRevoke     = "api_key.revoke"
+	auditFeedTokenCreate  = "feed_token.create"
+	auditFeedTokenDelete  = "feed_token.delete"
+	auditFeedCreate       = "feed.create"
+	auditFeedDelete       = "feed.delete"
+	auditFollowCreate     = "feed_follow.create"
+	auditFollowDelete     = "feed_follow.delete"
+	auditOPMLImport       = "opml.import"
+	auditAdminUserUpdate  = "admin.user_update"
+	auditAdminUserDelete  = "admin.user_delete"
+	auditAdminKeysReset   = "admin.api_keys_reset"
+	auditInviteCreate     = "admin.invite_create"
+	auditInviteDelete     = "admin.invite_delete"
+	auditPlanetCreate     = "planet.create"
+	auditPlanetDelete     = "planet.delete"
+	auditPlanetFeedAdd    = "planet.feed_add"
+	auditPlanetFeedRemove = "planet.feed_remove"
+)
+
+type auditEvent struct {
+	Action     string
+	TargetType string
+	TargetID   uuid.UUID
+	// The account the event is about, the actor when left empty
+	Subject uuid.UUID
+	Details map[string]any
+}
+
+// Appends an event to the audit log. Failing to record it is logged but
+// does not fail the request that caused it.
+func (self *apiConfig) audit(r *http.Request, actor uuid.UUID, event auditEvent) {
+	subject := event.Subject
+	if subject == uuid.Nil {
+		subject = actor
+	}
+	details := []byte("{}")
+	if event.Details != nil {
+		var err error
+		if details, err = json.Marshal(event.Details); err != nil {
+			log.Printf("Error encoding audit event details: %v", err.Error())
+			details = []byte("{}")
+		}
+	}
+
+	if err := self.DB.CreateAuditEvent(r.Context(), database.CreateAuditEventParams{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now().UTC(),
+		ActorID:    actor,
+		SubjectID:  subject,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   uuid.NullUUID{UUID: event.TargetID, Valid: event.TargetID != uuid.Nil},
+		Ip:         clientIP(r),
+		Details:    details,
+	}); err != nil {
+		log.Printf("Error recording audit event %s: %v", event.Action, err.Error())
+	}
+}
+
+// Lists events the user took part in, newest first. Admins can pass all=true
+// to see every event or user_id to see those of another user.
+func (self *apiConfig) getAuditEvents(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit, err := limitFromQuery(r)
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	before, err := timeFromQuery(r, "before")
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	userID := uuid.NullUUID{UUID: user.ID, Valid: true}
+	query := r.URL.Query()
+	if query.Get("all") == "true" || query.Get("user_id") != "" {
+		if user.Role != roleAdmin {
+			respondWithError(w, http.StatusForbidden, "Only admins can see the events of other users")
+			return
+		}
+		userID = uuid.NullUUID{}
+		if query.Get("user_id") != "" {
+			id, err := uuid.Parse(query.Get("user_id"))
+			if err != nil {
+				respondWithInvalidID(w, "user")
+				return
+			}
+			userID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+	}
+
+	events, err := self.DB.GetAuditEvents(r.Context(), database.GetAuditEventsParams{
+		UserID:     userID,
+		Action:     sql.NullString{String: query.Get("action"), Valid: query.Get("action") != ""},
+		Before:     before,
+		MaxResults: limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get audit events")
+		return
+	}
+	if events == nil {
+		events = []database.AuditEvent{}
+	}
+	respondWithJSON(w, http.StatusOK, events)
+}
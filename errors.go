@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Stable error codes clients can switch on, messages are for humans
+const (
+	codeBadRequest       = "bad_request"
+	codeInvalidJSON      = "invalid_json"
+	codeInvalidID        = "invalid_id"
+	codeInvalidParameter = "invalid_parameter"
+	codeBodyTooLarge     = "body_too_large"
+	codeValidationFailed = "validation_failed"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codeRateLimited      = "rate_limited"
+	codeInternal         = "internal_error"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+type apiError struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	RequestID string       `json:"request_id,omitempty"`
+	Fields    []fieldError `json:"fields,omitempty"`
+}
+
+// A problem with one field of the request body
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return codeBadRequest
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusForbidden:
+		return codeForbidden
+	case http.StatusNotFound:
+		return codeNotFound
+	case http.StatusMethodNotAllowed:
+		return codeMethodNotAllowed
+	case http.StatusConflict:
+		return codeConflict
+	case http.StatusRequestEntityTooLarge:
+		return codeBodyTooLarge
+	case http.StatusUnprocessableEntity:
+		return codeValidationFailed
+	case http.StatusTooManyRequests:
+		return codeRateLimited
+	}
+	return codeInternal
+}
+
+// Writes {"error": {...}}. The request ID comes from the response header set
+// by middlewareRequestID so it can be matched with the server logs.
+func respondWithAPIError(w http.ResponseWriter, status int, apiErr apiError) {
+	type errorResponse struct {
+		Error apiError `json:"error"`
+	}
+	apiErr.RequestID = w.Header().Get(requestIDHeader)
+	data, _ := json.Marshal(errorResponse{apiErr})
+	log.Printf("Error [%s] %s: %s", apiErr.RequestID, apiErr.Code, apiErr.Message)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func respondWithCode(w http.ResponseWriter, status int, code string, msg string) {
+	respondWithAPIError(w, status, apiError{Code: code, Message: msg})
+}
+
+// Answers 422 listing every invalid field at once
+func respondWithFieldErrors(w http.ResponseWriter, fields []fieldError) {
+	respondWithAPIError(w, http.StatusUnprocessableEntity, apiError{
+		Code:    codeValidationFailed,
+		Message: "Request body has invalid fields",
+		Fields:  fields,
+	})
+}
+
+func respondWithFieldError(w http.ResponseWriter, field string, msg string) {
+	respondWithFieldErrors(w, []fieldError{{field, msg}})
+}
+
+// Decoder errors quote the input back and are not shown as is
+func respondWithDecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithCode(w, http.StatusRequestEntityTooLarge, codeBodyTooLarge, "Request body is too large")
+		return
+	}
+	respondWithCode(w, http.StatusBadRequest, codeInvalidJSON, "Request body is not valid JSON")
+}
+
+func respondWithInvalidID(w http.ResponseWriter, name string) {
+	respondWithCode(w, http.StatusBadRequest, codeInvalidID, "Invalid "+name+" ID")
+}
+
+// Constraint violations are the client's fault and map to 409 or 422
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
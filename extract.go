@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	maxArticleSize     = 2 << 20
+	articleTimeout     = 10 * time.Second
+	maxArticlesPerFeed = 10
+)
+
+// Post links come from feeds anyone can add and the extracted page is shown
+// to the user, so they may only lead to public web servers. Addresses are
+// checked when connecting, which covers redirects and DNS answers alike.
+var articleClient = &http.Client{
+	Timeout: articleTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: articleTimeout,
+			Control: denyPrivateAddresses,
+		}).DialContext,
+		TLSHandshakeTimeout:   articleTimeout,
+		ResponseHeaderTimeout: articleTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return checkArticleURL(req.URL)
+	},
+}
+
+// 100.64.0.0/10, used by carrier-grade NAT and some cloud networks
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func denyPrivateAddresses(network string, address string, conn syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	ip := addrPort.Addr().Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("Address not allowed: %v", ip)
+	}
+	return nil
+}
+
+func checkArticleURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Not an http or https URL: %v", u.Redacted())
+	}
+	return nil
+}
+
+var (
+	positiveHint = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text`)
+	negativeHint = regexp.MustCompile(`(?i)ad-|ads|banner|comment|footer|header|menu|meta|nav|related|share|sidebar|social|sponsor|widget`)
+)
+
+// Downloads the page behind a post link and returns the HTML of its main content
+func fetchArticle(ctx context.Context, link string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := checkArticleURL(req.URL); err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "RSS_feed_bot/3.0")
+
+	resp, err := articleClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error: failed HTTP GET request - %v", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 399 {
+		return "", fmt.Errorf("Status error: %v", resp.Status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.Contains(contentType, "html") {
+		return "", fmt.Errorf("Not an HTML page: %v", contentType)
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxArticleSize))
+	if err != nil {
+		return "", err
+	}
+	return extractArticle(doc)
+}
+
+// A readability-style extractor: paragraphs give points to their parent and
+// grandparent, adjusted by class/id hints and link density, and the best
+// scoring element is taken as the article.
+func extractArticle(doc *html.Node) (string, error) {
+	scores := map[*html.Node]float64{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && droppedElements[n.DataAtom] {
+			return
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Td) {
+			text := nodeText(n)
+			if len(text) >= 25 {
+				score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+				if parent := n.Parent; parent != nil {
+					addScore(scores, parent, score)
+					if grandparent := parent.Parent; grandparent != nil {
+						addScore(scores, grandparent, score/2)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("No article content found")
+	}
+
+	var out strings.Builder
+	if err := html.Render(&out, best); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func addScore(scores map[*html.Node]float64, n *html.Node, score float64) {
+	if n.Type != html.ElementNode || n.DataAtom == atom.Body || n.DataAtom == atom.Html {
+		return
+	}
+	if _, ok := scores[n]; !ok {
+		scores[n] = classWeight(n)
+	}
+	scores[n] += score
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		weight += 10
+	case atom.Aside, atom.Footer, atom.Header, atom.Nav:
+		weight -= 25
+	}
+	for _, attr := range n.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if negativeHint.MatchString(attr.Val) {
+			weight -= 25
+		}
+		if positiveHint.MatchString(attr.Val) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// Share of the text of n that sits inside links
+func linkDensity(n *html.Node) float64 {
+	textLength := len(nodeText(n))
+	if textLength == 0 {
+		return 1
+	}
+	linkLength := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linkLength += len(nodeText(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(linkLength) / float64(textLength)
+}
+
+func nodeText(n *html.Node) string {
+	var out strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && droppedElements[n.DataAtom] {
+			return
+		}
+		if n.Type == html.TextNode {
+			out.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(out.String()), " ")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDenyPrivateAddresses(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"127.0.0.1:80", false},
+		{"127.1.2.3:80", false},
+		{"10.1.2.3:443", false},
+		{"172.16.0.1:80", false},
+		{"172.31.255.255:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"100.64.0.1:80", false},
+		{"100.127.255.255:80", false},
+		{"0.0.0.0:80", false},
+		{"224.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"[::]:80", false},
+		{"[fc00::1]:80", false},
+		{"[fd12:3456::1]:80", false},
+		{"[fe80::1]:80", false},
+		{"[ff02::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"[::ffff:10.0.0.1]:80", false},
+		{"[::ffff:169.254.169.254]:80", false},
+		{"93.184.216.34:80", true},
+		{"100.63.255.255:80", true},
+		{"100.128.0.1:80", true},
+		{"172.32.0.1:80", true},
+		{"[2606:2800:220:1::1]:443", true},
+		{"[::ffff:93.184.216.34]:80", true},
+	}
+	for _, tt := range tests {
+		err := denyPrivateAddresses("tcp", tt.address, nil)
+		if (err == nil) != tt.allowed {
+			t.Errorf("denyPrivateAddresses(%q) = %v, want allowed %v", tt.address, err, tt.allowed)
+		}
+	}
+}
+
+func TestExtractArticle(t *testing.T) {
+	page := `<html><body>
+<nav><ul><li><a href="/">Home, about, archive and more links here</a></li></ul></nav>
+<div class="sidebar"><p>Subscribe to the newsletter, follow us, share this post.</p></div>
+<article><h1>Title</h1>
+<p>The first paragraph of the article, long enough to count as content.</p>
+<p>A second paragraph, with a few commas, so that it scores higher still.</p>
+<script>alert("not content")</script>
+</article>
+<footer><p>Copyright, imprint, privacy policy and the rest of the footer.</p></footer>
+</body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := extractArticle(doc)
+	if err != nil {
+		t.Fatalf("extractArticle() error: %v", err)
+	}
+	if !strings.HasPrefix(article, "<article>") || !strings.Contains(article, "second paragraph") {
+		t.Errorf("extractArticle() picked the wrong element: %s", article)
+	}
+
+	doc, _ = html.Parse(strings.NewReader(`<p>Too short</p><nav><p>Only navigation, nothing else of interest.</p></nav>`))
+	if _, err := extractArticle(doc); err == nil {
+		t.Error("extractArticle() found content in a page without any")
+	}
+}
+
+func TestFetchArticle(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><body><main><p>An article served over HTTP, long enough to be content.</p></main></body></html>`))
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("An article served as plain text, long enough to be content."))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// The test server listens on loopback, which the article client refuses
+	if _, err := fetchArticle(context.Background(), server.URL+"/post"); err == nil || !strings.Contains(err.Error(), "Address not allowed") {
+		t.Errorf("fetching from loopback gave %v, want address not allowed", err)
+	}
+
+	client := *articleClient
+	client.Transport = server.Client().Transport
+	defer func(original *http.Client) { articleClient = original }(articleClient)
+	articleClient = &client
+
+	article, err := fetchArticle(context.Background(), server.URL+"/post")
+	if err != nil {
+		t.Fatalf("fetchArticle() error: %v", err)
+	}
+	if !strings.Contains(article, "An article served over HTTP") {
+		t.Errorf("fetchArticle() = %q", article)
+	}
+
+	for _, link := range []string{
+		server.URL + "/text",
+		server.URL + "/redirect",
+		server.URL + "/missing",
+		"file:///etc/passwd",
+		"ftp://example.com/article",
+	} {
+		if _, err := fetchArticle(context.Background(), link); err == nil {
+			t.Errorf("fetchArticle(%q) succeeded", link)
+		}
+	}
+}
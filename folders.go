@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+// Items of type T grouped under one of the user's folders.
+// A nil Folder holds the items that are not filed anywhere.
+type folderGroup[T any] struct {
+	Folder *database.Folder `json:"folder"`
+	Items  []T              `json:"items"`
+}
+
+func groupByFolder[T any](folders []database.Folder, items []T, folderOf func(T) uuid.NullUUID) []folderGroup[T] {
+	groups := make([]folderGroup[T], len(folders)+1)
+	index := make(map[uuid.UUID]int, len(folders))
+	for i := range folders {
+		groups[i] = folderGroup[T]{Folder: &folders[i], Items: []T{}}
+		index[folders[i].ID] = i
+	}
+	unfiled := len(folders)
+	groups[unfiled] = folderGroup[T]{Items: []T{}}
+
+	for _, item := range items {
+		i := unfiled
+		if folderID := folderOf(item); folderID.Valid {
+			if idx, ok := index[folderID.UUID]; ok {
+				i = idx
+			}
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+	return groups
+}
+
+// Parses the optional "folder_id" query parameter
+func folderFromQuery(r *http.Request) (uuid.NullUUID, error) {
+	folderStr := r.URL.Query().Get("folder_id")
+	if folderStr == "" {
+		return uuid.NullUUID{}, nil
+	}
+	folderID, err := uuid.Parse(folderStr)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("Invalid folder_id: %q", folderStr)
+	}
+	return uuid.NullUUID{UUID: folderID, Valid: true}, nil
+}
+
+func (self *apiConfig) postCreateFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	v := validator{}
+	v.required("name", params.Name)
+	v.maxLength("name", params.Name, maxNameLength)
+	if v.failed(w) {
+		return
+	}
+
+	folder, err := self.DB.CreateFolder(r.Context(), database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Name:      params.Name,
+		UserID:    user.ID,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "A folder with this name already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create folder")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, folder)
+}
+
+func (self *apiConfig) getUserFolders(w http.ResponseWriter, r *http.Request, user database.User) {
+	folders, err := self.DB.GetUserFolders(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get folders")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, folders)
+}
+
+func (self *apiConfig) putRenameFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	folderID, err := uuid.Parse(r.PathValue("folderID"))
+	if err != nil {
+		respondWithInvalidID(w, "folder")
+		return
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	v := validator{}
+	v.required("name", params.Name)
+	v.maxLength("name", params.Name, maxNameLength)
+	if v.failed(w) {
+		return
+	}
+
+	folder, err := self.DB.RenameFolder(r.Context(), database.RenameFolderParams{
+		Name:      params.Name,
+		UpdatedAt: time.Now().UTC(),
+		ID:        folderID,
+		UserID:    user.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "A folder with this name already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not rename folder")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, folder)
+}
+
+func (self *apiConfig) deleteFolder(w http.ResponseWriter, r *http.Request, user database.User) {
+	folderID, err := uuid.Parse(r.PathValue("folderID"))
+	if err != nil {
+		respondWithInvalidID(w, "folder")
+		return
+	}
+
+	// Feed follows in the folder are kept and become unfiled (ON DELETE SET NULL)
+	deleted, err := self.DB.DeleteFolder(r.Context(), database.DeleteFolderParams{
+		ID:     folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete folder")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+// Checks that the requested folder belongs to the user
+func (self *apiConfig) userFolderID(ctx context.Context, user database.User, folderID *uuid.UUID) (uuid.NullUUID, error) {
+	if folderID == nil {
+		return uuid.NullUUID{}, nil
+	}
+	folder, err := self.DB.GetUserFolder(ctx, database.GetUserFolderParams{
+		ID:     *folderID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: folder.ID, Valid: true}, nil
+}
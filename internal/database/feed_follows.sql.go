@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,17 +15,19 @@ import (
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 INSERT INTO feed_follows(
-  id, created_at, updated_at, feed_id, user_id
-) VALUES($1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, feed_id, user_id
+  id, created_at, updated_at, feed_id, user_id, title, folder_id
+) VALUES($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at, updated_at, feed_id, user_id, title, folder_id, fetch_full_text
 `
 
 type CreateFeedFollowParams struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	FeedID    uuid.UUID `json:"feed_id"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID        uuid.UUID      `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	FeedID    uuid.UUID      `json:"feed_id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	Title     sql.NullString `json:"title"`
+	FolderID  uuid.NullUUID  `json:"folder_id"`
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (FeedFollow, error) {
@@ -34,6 +37,8 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.FeedID,
 		arg.UserID,
+		arg.Title,
+		arg.FolderID,
 	)
 	var i FeedFollow
 	err := row.Scan(
@@ -42,6 +47,9 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.FeedID,
 		&i.UserID,
+		&i.Title,
+		&i.FolderID,
+		&i.FetchFullText,
 	)
 	return i, err
 }
@@ -56,8 +64,34 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getUserFeedFollow = `-- name: GetUserFeedFollow :one
+SELECT id, created_at, updated_at, feed_id, user_id, title, folder_id, fetch_full_text FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type GetUserFeedFollowParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	FeedID uuid.UUID `json:"feed_id"`
+}
+
+func (q *Queries) GetUserFeedFollow(ctx context.Context, arg GetUserFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getUserFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.UserID,
+		&i.Title,
+		&i.FolderID,
+		&i.FetchFullText,
+	)
+	return i, err
+}
+
 const getUserFeedFollows = `-- name: GetUserFeedFollows :many
-SELECT id, created_at, updated_at, feed_id, user_id FROM feed_follows
+SELECT id, created_at, updated_at, feed_id, user_id, title, folder_id, fetch_full_text FROM feed_follows
 WHERE user_id = $1
 `
 
@@ -76,6 +110,9 @@ func (q *Queries) GetUserFeedFollows(ctx context.Context, userID uuid.UUID) ([]F
 			&i.UpdatedAt,
 			&i.FeedID,
 			&i.UserID,
+			&i.Title,
+			&i.FolderID,
+			&i.FetchFullText,
 		); err != nil {
 			return nil, err
 		}
@@ -89,3 +126,163 @@ func (q *Queries) GetUserFeedFollows(ctx context.Context, userID uuid.UUID) ([]F
 	}
 	return items, nil
 }
+
+const getUserFeedFollowsInFolder = `-- name: GetUserFeedFollowsInFolder :many
+SELECT id, created_at, updated_at, feed_id, user_id, title, folder_id, fetch_full_text FROM feed_follows
+WHERE user_id = $1 AND folder_id = $2
+`
+
+type GetUserFeedFollowsInFolderParams struct {
+	UserID   uuid.UUID     `json:"user_id"`
+	FolderID uuid.NullUUID `json:"folder_id"`
+}
+
+func (q *Queries) GetUserFeedFollowsInFolder(ctx context.Context, arg GetUserFeedFollowsInFolderParams) ([]FeedFollow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserFeedFollowsInFolder, arg.UserID, arg.FolderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeedFollow
+	for rows.Next() {
+		var i FeedFollow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FeedID,
+			&i.UserID,
+			&i.Title,
+			&i.FolderID,
+			&i.FetchFullText,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserSubscriptions = `-- name: GetUserSubscriptions :many
+SELECT feed_follows.id, feed_follows.title, feeds.name AS feed_name, feeds.url AS feed_url, folders.name AS folder_name
+FROM feed_follows
+JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN folders ON folders.id = feed_follows.folder_id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name NULLS FIRST, feeds.name
+`
+
+type GetUserSubscriptionsRow struct {
+	ID         uuid.UUID      `json:"id"`
+	Title      sql.NullString `json:"title"`
+	FeedName   string         `json:"feed_name"`
+	FeedUrl    string         `json:"feed_url"`
+	FolderName sql.NullString `json:"folder_name"`
+}
+
+func (q *Queries) GetUserSubscriptions(ctx context.Context, userID uuid.UUID) ([]GetUserSubscriptionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserSubscriptions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserSubscriptionsRow
+	for rows.Next() {
+		var i GetUserSubscriptionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.FolderName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setFeedFollowFullText = `-- name: SetFeedFollowFullText :one
+UPDATE feed_follows
+  SET fetch_full_text = $1, updated_at = $2
+  WHERE id = $3 AND user_id = $4
+RETURNING id, created_at, updated_at, feed_id, user_id, title, folder_id, fetch_full_text
+`
+
+type SetFeedFollowFullTextParams struct {
+	FetchFullText bool      `json:"fetch_full_text"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uuid.UUID `json:"id"`
+	UserID        uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) SetFeedFollowFullText(ctx context.Context, arg SetFeedFollowFullTextParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, setFeedFollowFullText,
+		arg.FetchFullText,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.UserID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.UserID,
+		&i.Title,
+		&i.FolderID,
+		&i.FetchFullText,
+	)
+	return i, err
+}
+
+const updateFeedFollow = `-- name: UpdateFeedFollow :one
+UPDATE feed_follows
+  SET title = $1, folder_id = $2, updated_at = $3
+  WHERE id = $4 AND user_id = $5
+RETURNING id, created_at, updated_at, feed_id, user_id, title, folder_id, fetch_full_text
+`
+
+type UpdateFeedFollowParams struct {
+	Title     sql.NullString `json:"title"`
+	FolderID  uuid.NullUUID  `json:"folder_id"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	ID        uuid.UUID      `json:"id"`
+	UserID    uuid.UUID      `json:"user_id"`
+}
+
+func (q *Queries) UpdateFeedFollow(ctx context.Context, arg UpdateFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, updateFeedFollow,
+		arg.Title,
+		arg.FolderID,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.UserID,
+	)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FeedID,
+		&i.UserID,
+		&i.Title,
+		&i.FolderID,
+		&i.FetchFullText,
+	)
+	return i, err
+}
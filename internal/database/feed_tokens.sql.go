@@ -0,0 +1,75 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: feed_tokens.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const deleteFeedToken = `-- name: DeleteFeedToken :execrows
+DELETE FROM feed_tokens
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteFeedToken(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedToken, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getUserByFeedToken = `-- name: GetUserByFeedToken :one
+SELECT users.id, users.created_at, users.updated_at, users.name, users.role, users.disabled_at FROM feed_tokens
+JOIN users ON users.id = feed_tokens.user_id
+WHERE feed_tokens.user_id = $1 AND feed_tokens.token_hash = $2
+  AND users.disabled_at IS NULL
+`
+
+type GetUserByFeedTokenParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+}
+
+func (q *Queries) GetUserByFeedToken(ctx context.Context, arg GetUserByFeedTokenParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByFeedToken, arg.UserID, arg.TokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Role,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const upsertFeedToken = `-- name: UpsertFeedToken :one
+INSERT INTO feed_tokens (
+  user_id, created_at, token_hash
+) VALUES ( $1, $2, $3 )
+ON CONFLICT (user_id) DO UPDATE
+  SET created_at = EXCLUDED.created_at,
+      token_hash = EXCLUDED.token_hash
+RETURNING user_id, created_at, token_hash
+`
+
+type UpsertFeedTokenParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	TokenHash string    `json:"token_hash"`
+}
+
+func (q *Queries) UpsertFeedToken(ctx context.Context, arg UpsertFeedTokenParams) (FeedToken, error) {
+	row := q.db.QueryRowContext(ctx, upsertFeedToken, arg.UserID, arg.CreatedAt, arg.TokenHash)
+	var i FeedToken
+	err := row.Scan(&i.UserID, &i.CreatedAt, &i.TokenHash)
+	return i, err
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeed = `-- name: CreateFeed :one
@@ -21,13 +22,13 @@ RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
 `
 
 type CreateFeedParams struct {
-	ID            uuid.UUID    `json:"id"`
-	CreatedAt     time.Time    `json:"created_at"`
-	UpdatedAt     time.Time    `json:"updated_at"`
-	Name          string       `json:"name"`
-	Url           string       `json:"url"`
-	UserID        uuid.UUID    `json:"user_id"`
-	LastFetchedAt sql.NullTime `json:"last_fetched_at"`
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Name          string        `json:"name"`
+	Url           string        `json:"url"`
+	UserID        uuid.NullUUID `json:"user_id"`
+	LastFetchedAt sql.NullTime  `json:"last_fetched_at"`
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -53,6 +54,63 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 	return i, err
 }
 
+const deleteUnfollowedOrphanFeeds = `-- name: DeleteUnfollowedOrphanFeeds :many
+DELETE FROM feeds
+WHERE user_id IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM feed_follows
+    WHERE feed_follows.feed_id = feeds.id
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM planet_feeds
+    WHERE planet_feeds.feed_id = feeds.id
+  )
+RETURNING id, name, url
+`
+
+type DeleteUnfollowedOrphanFeedsRow struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Url  string    `json:"url"`
+}
+
+func (q *Queries) DeleteUnfollowedOrphanFeeds(ctx context.Context) ([]DeleteUnfollowedOrphanFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, deleteUnfollowedOrphanFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteUnfollowedOrphanFeedsRow
+	for rows.Next() {
+		var i DeleteUnfollowedOrphanFeedsRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.Url); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const feedNeedsFullText = `-- name: FeedNeedsFullText :one
+SELECT EXISTS (
+  SELECT 1 FROM feed_follows
+  WHERE feed_follows.feed_id = $1 AND feed_follows.fetch_full_text
+)
+`
+
+func (q *Queries) FeedNeedsFullText(ctx context.Context, feedID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, feedNeedsFullText, feedID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const getAllFeeds = `-- name: GetAllFeeds :many
 SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
 `
@@ -88,6 +146,62 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]Feed, error) {
 	return items, nil
 }
 
+const getFeedByUrl = `-- name: GetFeedByUrl :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+WHERE url = $1
+`
+
+func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByUrl, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+	)
+	return i, err
+}
+
+const getFeedsByIDs = `-- name: GetFeedsByIDs :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetFeedsByIDs(ctx context.Context, ids []uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
 SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
 ORDER BY last_fetched_at NULLS FIRST
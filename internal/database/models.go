@@ -6,44 +6,174 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	UserID      uuid.UUID     `json:"user_id"`
+	Name        string        `json:"name"`
+	Prefix      string        `json:"prefix"`
+	KeyHash     string        `json:"key_hash"`
+	LastUsedAt  sql.NullTime  `json:"last_used_at"`
+	RevokedAt   sql.NullTime  `json:"revoked_at"`
+	ExpiresAt   sql.NullTime  `json:"expires_at"`
+	RotatedAt   sql.NullTime  `json:"rotated_at"`
+	RotatedFrom uuid.NullUUID `json:"rotated_from"`
+	Scopes      []string      `json:"scopes"`
+}
+
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ActorID    uuid.UUID       `json:"actor_id"`
+	SubjectID  uuid.UUID       `json:"subject_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   uuid.NullUUID   `json:"target_id"`
+	Ip         string          `json:"ip"`
+	Details    json.RawMessage `json:"details"`
+}
+
+type Credential struct {
+	UserID       uuid.UUID `json:"user_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"password_hash"`
+}
+
 type Feed struct {
-	ID            uuid.UUID    `json:"id"`
-	CreatedAt     time.Time    `json:"created_at"`
-	UpdatedAt     time.Time    `json:"updated_at"`
-	Name          string       `json:"name"`
-	Url           string       `json:"url"`
-	UserID        uuid.UUID    `json:"user_id"`
-	LastFetchedAt sql.NullTime `json:"last_fetched_at"`
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Name          string        `json:"name"`
+	Url           string        `json:"url"`
+	UserID        uuid.NullUUID `json:"user_id"`
+	LastFetchedAt sql.NullTime  `json:"last_fetched_at"`
 }
 
 type FeedFollow struct {
+	ID            uuid.UUID      `json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	FeedID        uuid.UUID      `json:"feed_id"`
+	UserID        uuid.UUID      `json:"user_id"`
+	Title         sql.NullString `json:"title"`
+	FolderID      uuid.NullUUID  `json:"folder_id"`
+	FetchFullText bool           `json:"fetch_full_text"`
+}
+
+type FeedToken struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	TokenHash string    `json:"token_hash"`
+}
+
+type Folder struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	FeedID    uuid.UUID `json:"feed_id"`
+	Name      string    `json:"name"`
 	UserID    uuid.UUID `json:"user_id"`
 }
 
-type Post struct {
+type InviteCode struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	CreatedBy uuid.NullUUID `json:"created_by"`
+	Code      string        `json:"code"`
+	ExpiresAt sql.NullTime  `json:"expires_at"`
+	UsedAt    sql.NullTime  `json:"used_at"`
+	UsedBy    uuid.NullUUID `json:"used_by"`
+}
+
+type Planet struct {
 	ID          uuid.UUID `json:"id"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	Slug        string    `json:"slug"`
 	Title       string    `json:"title"`
-	Url         string    `json:"url"`
 	Description string    `json:"description"`
-	PublishedAt string    `json:"published_at"`
-	FeedID      uuid.UUID `json:"feed_id"`
 }
 
-type User struct {
+type PlanetFeed struct {
+	PlanetID  uuid.UUID `json:"planet_id"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PodcastEpisode struct {
+	ID             uuid.UUID     `json:"id"`
+	PostID         uuid.UUID     `json:"post_id"`
+	Episode        sql.NullInt32 `json:"episode"`
+	ImageUrl       string        `json:"image_url"`
+	TranscriptUrl  string        `json:"transcript_url"`
+	TranscriptType string        `json:"transcript_type"`
+}
+
+type Post struct {
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Title       string        `json:"title"`
+	Url         string        `json:"url"`
+	Description string        `json:"description"`
+	PublishedAt time.Time     `json:"published_at"`
+	FeedID      uuid.NullUUID `json:"feed_id"`
+	Author      string        `json:"author"`
+	Content     string        `json:"content"`
+	Search      interface{}   `json:"search"`
+	Guid        string        `json:"guid"`
+	CommentsUrl string        `json:"comments_url"`
+	Article     string        `json:"article"`
+}
+
+type PostCategory struct {
+	ID     uuid.UUID `json:"id"`
+	PostID uuid.UUID `json:"post_id"`
+	Name   string    `json:"name"`
+}
+
+type PostEnclosure struct {
+	ID       uuid.UUID     `json:"id"`
+	PostID   uuid.UUID     `json:"post_id"`
+	Url      string        `json:"url"`
+	Type     string        `json:"type"`
+	Length   sql.NullInt64 `json:"length"`
+	Duration sql.NullInt32 `json:"duration"`
+}
+
+type PostState struct {
+	ID               uuid.UUID     `json:"id"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+	UserID           uuid.UUID     `json:"user_id"`
+	PostID           uuid.UUID     `json:"post_id"`
+	ReadAt           sql.NullTime  `json:"read_at"`
+	StarredAt        sql.NullTime  `json:"starred_at"`
+	PlaybackPosition sql.NullInt32 `json:"playback_position"`
+}
+
+type Session struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Name      string    `json:"name"`
-	ApiKey    string    `json:"api_key"`
+	UserID    uuid.UUID `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	CsrfToken string    `json:"csrf_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type User struct {
+	ID         uuid.UUID    `json:"id"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	Name       string       `json:"name"`
+	Role       string       `json:"role"`
+	DisabledAt sql.NullTime `json:"disabled_at"`
 }
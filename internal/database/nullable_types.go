@@ -9,24 +9,143 @@ import (
 )
 
 type JSONFeed struct {
-	ID            uuid.UUID `json:"id"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	Name          string    `json:"name"`
-	Url           string    `json:"url"`
-	UserID        uuid.UUID `json:"user_id"`
-	LastFetchedAt NullTime  `json:"last_fetched_at"`
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Name          string        `json:"name"`
+	Url           string        `json:"url"`
+	UserID        uuid.NullUUID `json:"user_id"`
+	LastFetchedAt NullTime      `json:"last_fetched_at"`
+}
+
+type JSONFeedFollow struct {
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	FeedID        uuid.UUID     `json:"feed_id"`
+	UserID        uuid.UUID     `json:"user_id"`
+	Title         NullString    `json:"title"`
+	FolderID      uuid.NullUUID `json:"folder_id"`
+	FetchFullText bool          `json:"fetch_full_text"`
+}
+
+type JSONPost struct {
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Title       string        `json:"title"`
+	Url         string        `json:"url"`
+	Description string        `json:"description"`
+	PublishedAt time.Time     `json:"published_at"`
+	FeedID      uuid.NullUUID `json:"feed_id"`
+	Author      string        `json:"author"`
+	Content     string        `json:"content"`
+	// Main content of the linked page, for feeds with full text fetching
+	Article string `json:"article"`
+	// Plain text renderings of Description, Content and Article
+	DescriptionText string          `json:"description_text"`
+	ContentText     string          `json:"content_text"`
+	ArticleText     string          `json:"article_text"`
+	Guid            string          `json:"guid"`
+	CommentsUrl     string          `json:"comments_url"`
+	Categories      []string        `json:"categories"`
+	Enclosures      []JSONEnclosure `json:"enclosures"`
+}
+
+type JSONEnclosure struct {
+	Url      string    `json:"url"`
+	Type     string    `json:"type"`
+	Length   NullInt64 `json:"length"`
+	Duration NullInt32 `json:"duration"`
+}
+
+type JSONEpisode struct {
+	ID               uuid.UUID       `json:"id"`
+	Title            string          `json:"title"`
+	Url              string          `json:"url"`
+	Description      string          `json:"description"`
+	DescriptionText  string          `json:"description_text"`
+	PublishedAt      time.Time       `json:"published_at"`
+	FeedID           uuid.NullUUID   `json:"feed_id"`
+	Episode          NullInt32       `json:"episode"`
+	ImageUrl         string          `json:"image_url"`
+	TranscriptUrl    string          `json:"transcript_url"`
+	TranscriptType   string          `json:"transcript_type"`
+	PlaybackPosition NullInt32       `json:"playback_position"`
+	Read             bool            `json:"read"`
+	Enclosures       []JSONEnclosure `json:"enclosures"`
+}
+
+// API keys without their hash
+type JSONApiKey struct {
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Name        string        `json:"name"`
+	Prefix      string        `json:"prefix"`
+	Scopes      []string      `json:"scopes"`
+	LastUsedAt  NullTime      `json:"last_used_at"`
+	RevokedAt   NullTime      `json:"revoked_at"`
+	ExpiresAt   NullTime      `json:"expires_at"`
+	RotatedAt   NullTime      `json:"rotated_at"`
+	RotatedFrom uuid.NullUUID `json:"rotated_from"`
+}
+
+type JSONUser struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Name       string    `json:"name"`
+	Role       string    `json:"role"`
+	DisabledAt NullTime  `json:"disabled_at"`
+}
+
+type JSONInviteCode struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	CreatedBy uuid.NullUUID `json:"created_by"`
+	Code      string        `json:"code"`
+	ExpiresAt NullTime      `json:"expires_at"`
+	UsedAt    NullTime      `json:"used_at"`
+	UsedBy    uuid.NullUUID `json:"used_by"`
 }
 
 type NullTime sql.NullTime
 
-func (self *NullTime) MarshalJSON() ([]byte, error) {
+type NullString sql.NullString
+
+type NullInt64 sql.NullInt64
+
+type NullInt32 sql.NullInt32
+
+func (self NullTime) MarshalJSON() ([]byte, error) {
 	if !self.Valid {
 		return json.Marshal(nil)
 	}
 	return json.Marshal(self.Time)
 }
 
+func (self NullString) MarshalJSON() ([]byte, error) {
+	if !self.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(self.String)
+}
+
+func (self NullInt64) MarshalJSON() ([]byte, error) {
+	if !self.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(self.Int64)
+}
+
+func (self NullInt32) MarshalJSON() ([]byte, error) {
+	if !self.Valid {
+		return json.Marshal(nil)
+	}
+	return json.Marshal(self.Int32)
+}
+
 func (self *Feed) Json() JSONFeed {
 	return JSONFeed{
 		ID:            self.ID,
@@ -38,3 +157,102 @@ func (self *Feed) Json() JSONFeed {
 		LastFetchedAt: NullTime(self.LastFetchedAt),
 	}
 }
+
+func (self *FeedFollow) Json() JSONFeedFollow {
+	return JSONFeedFollow{
+		ID:            self.ID,
+		CreatedAt:     self.CreatedAt,
+		UpdatedAt:     self.UpdatedAt,
+		FeedID:        self.FeedID,
+		UserID:        self.UserID,
+		Title:         NullString(self.Title),
+		FolderID:      self.FolderID,
+		FetchFullText: self.FetchFullText,
+	}
+}
+
+func (self *Post) Json() JSONPost {
+	return JSONPost{
+		ID:          self.ID,
+		CreatedAt:   self.CreatedAt,
+		UpdatedAt:   self.UpdatedAt,
+		Title:       self.Title,
+		Url:         self.Url,
+		Description: self.Description,
+		PublishedAt: self.PublishedAt,
+		FeedID:      self.FeedID,
+		Author:      self.Author,
+		Content:     self.Content,
+		Article:     self.Article,
+		Guid:        self.Guid,
+		CommentsUrl: self.CommentsUrl,
+		Categories:  []string{},
+		Enclosures:  []JSONEnclosure{},
+	}
+}
+
+func (self *PostEnclosure) Json() JSONEnclosure {
+	return JSONEnclosure{
+		Url:      self.Url,
+		Type:     self.Type,
+		Length:   NullInt64(self.Length),
+		Duration: NullInt32(self.Duration),
+	}
+}
+
+func (self *GetUserPodcastEpisodesRow) Json() JSONEpisode {
+	return JSONEpisode{
+		ID:               self.ID,
+		Title:            self.Title,
+		Url:              self.Url,
+		Description:      self.Description,
+		PublishedAt:      self.PublishedAt,
+		FeedID:           self.FeedID,
+		Episode:          NullInt32(self.Episode),
+		ImageUrl:         self.ImageUrl,
+		TranscriptUrl:    self.TranscriptUrl,
+		TranscriptType:   self.TranscriptType,
+		PlaybackPosition: NullInt32(self.PlaybackPosition),
+		Read:             self.ReadAt.Valid,
+		Enclosures:       []JSONEnclosure{},
+	}
+}
+
+func (self *ApiKey) Json() JSONApiKey {
+	return JSONApiKey{
+		ID:          self.ID,
+		CreatedAt:   self.CreatedAt,
+		UpdatedAt:   self.UpdatedAt,
+		Name:        self.Name,
+		Prefix:      self.Prefix,
+		Scopes:      self.Scopes,
+		LastUsedAt:  NullTime(self.LastUsedAt),
+		RevokedAt:   NullTime(self.RevokedAt),
+		ExpiresAt:   NullTime(self.ExpiresAt),
+		RotatedAt:   NullTime(self.RotatedAt),
+		RotatedFrom: self.RotatedFrom,
+	}
+}
+
+func (self *User) Json() JSONUser {
+	return JSONUser{
+		ID:         self.ID,
+		CreatedAt:  self.CreatedAt,
+		UpdatedAt:  self.UpdatedAt,
+		Name:       self.Name,
+		Role:       self.Role,
+		DisabledAt: NullTime(self.DisabledAt),
+	}
+}
+
+func (self *InviteCode) Json() JSONInviteCode {
+	return JSONInviteCode{
+		ID:        self.ID,
+		CreatedAt: self.CreatedAt,
+		CreatedBy: self.CreatedBy,
+		Code:      self.Code,
+		ExpiresAt: NullTime(self.ExpiresAt),
+		UsedAt:    NullTime(self.UsedAt),
+		UsedBy:    self.UsedBy,
+	}
+}
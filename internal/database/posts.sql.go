@@ -7,26 +7,33 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createPost = `-- name: CreatePost :exec
 INSERT INTO posts (
-  id, created_at, updated_at, title, url, description, published_at, feed_id
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+  id, created_at, updated_at, title, url, description, published_at, feed_id, author,
+  content, guid, comments_url
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 `
 
 type CreatePostParams struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Title       string    `json:"title"`
-	Url         string    `json:"url"`
-	Description string    `json:"description"`
-	PublishedAt string    `json:"published_at"`
-	FeedID      uuid.UUID `json:"feed_id"`
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Title       string        `json:"title"`
+	Url         string        `json:"url"`
+	Description string        `json:"description"`
+	PublishedAt time.Time     `json:"published_at"`
+	FeedID      uuid.NullUUID `json:"feed_id"`
+	Author      string        `json:"author"`
+	Content     string        `json:"content"`
+	Guid        string        `json:"guid"`
+	CommentsUrl string        `json:"comments_url"`
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
@@ -39,34 +46,212 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Author,
+		arg.Content,
+		arg.Guid,
+		arg.CommentsUrl,
 	)
 	return err
 }
 
-const getPostByUser = `-- name: GetPostByUser :many
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts
-WHERE posts.feed_id in (
-  select feed_follows.feed_id from feed_follows
-  where feed_follows.user_id = $1
-)
-ORDER BY posts.published_at DESC
-LIMIT $2
+const createPostCategory = `-- name: CreatePostCategory :exec
+INSERT INTO post_categories (
+  id, post_id, name
+) VALUES ($1, $2, $3)
+ON CONFLICT (post_id, name) DO NOTHING
+`
+
+type CreatePostCategoryParams struct {
+	ID     uuid.UUID `json:"id"`
+	PostID uuid.UUID `json:"post_id"`
+	Name   string    `json:"name"`
+}
+
+func (q *Queries) CreatePostCategory(ctx context.Context, arg CreatePostCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, createPostCategory, arg.ID, arg.PostID, arg.Name)
+	return err
+}
+
+const createPostEnclosure = `-- name: CreatePostEnclosure :exec
+INSERT INTO post_enclosures (
+  id, post_id, url, type, length, duration
+) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (post_id, url) DO NOTHING
+`
+
+type CreatePostEnclosureParams struct {
+	ID       uuid.UUID     `json:"id"`
+	PostID   uuid.UUID     `json:"post_id"`
+	Url      string        `json:"url"`
+	Type     string        `json:"type"`
+	Length   sql.NullInt64 `json:"length"`
+	Duration sql.NullInt32 `json:"duration"`
+}
+
+func (q *Queries) CreatePostEnclosure(ctx context.Context, arg CreatePostEnclosureParams) error {
+	_, err := q.db.ExecContext(ctx, createPostEnclosure,
+		arg.ID,
+		arg.PostID,
+		arg.Url,
+		arg.Type,
+		arg.Length,
+		arg.Duration,
+	)
+	return err
+}
+
+const deleteUnstarredOrphanPost = `-- name: DeleteUnstarredOrphanPost :exec
+DELETE FROM posts
+WHERE posts.id = $1
+  AND feed_id IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.starred_at IS NOT NULL
+  )
+`
+
+func (q *Queries) DeleteUnstarredOrphanPost(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUnstarredOrphanPost, id)
+	return err
+}
+
+const deleteUnstarredOrphanPosts = `-- name: DeleteUnstarredOrphanPosts :exec
+DELETE FROM posts
+WHERE feed_id IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.starred_at IS NOT NULL
+  )
+`
+
+func (q *Queries) DeleteUnstarredOrphanPosts(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteUnstarredOrphanPosts)
+	return err
+}
+
+const getPostsCategories = `-- name: GetPostsCategories :many
+SELECT id, post_id, name FROM post_categories
+WHERE post_id = ANY($1::uuid[])
+ORDER BY name
+`
+
+func (q *Queries) GetPostsCategories(ctx context.Context, postIds []uuid.UUID) ([]PostCategory, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsCategories, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostCategory
+	for rows.Next() {
+		var i PostCategory
+		if err := rows.Scan(&i.ID, &i.PostID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsEnclosures = `-- name: GetPostsEnclosures :many
+SELECT id, post_id, url, type, length, duration FROM post_enclosures
+WHERE post_id = ANY($1::uuid[])
 `
 
-type GetPostByUserParams struct {
-	UserID uuid.UUID `json:"user_id"`
-	Limit  int32     `json:"limit"`
+func (q *Queries) GetPostsEnclosures(ctx context.Context, postIds []uuid.UUID) ([]PostEnclosure, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsEnclosures, pq.Array(postIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostEnclosure
+	for rows.Next() {
+		var i PostEnclosure
+		if err := rows.Scan(
+			&i.ID,
+			&i.PostID,
+			&i.Url,
+			&i.Type,
+			&i.Length,
+			&i.Duration,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT ranked.id, ranked.created_at, ranked.updated_at, ranked.title, ranked.url,
+  ranked.description, ranked.published_at, ranked.feed_id, ranked.author, ranked.rank,
+  ts_headline('english', ranked.title, to_tsquery('english', $1),
+    'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS title_highlight,
+  ts_headline('english', ranked.description || ' ' || ranked.content, to_tsquery('english', $1),
+    'MaxFragments=2, MaxWords=30, MinWords=10, StartSel=<mark>, StopSel=</mark>')::text AS snippet
+FROM (
+  SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.content, posts.search, posts.guid, posts.comments_url, posts.article, ts_rank(posts.search, to_tsquery('english', $1)) AS rank
+  FROM posts
+  WHERE posts.search @@ to_tsquery('english', $1)
+    AND ($2::bool OR posts.feed_id IN (
+      select feed_follows.feed_id from feed_follows
+      where feed_follows.user_id = $3
+    ))
+  ORDER BY rank DESC, posts.published_at DESC
+  LIMIT $4
+) AS ranked
+ORDER BY ranked.rank DESC, ranked.published_at DESC
+`
+
+type SearchPostsParams struct {
+	Query      string    `json:"query"`
+	AllFeeds   bool      `json:"all_feeds"`
+	UserID     uuid.UUID `json:"user_id"`
+	MaxResults int32     `json:"max_results"`
 }
 
-func (q *Queries) GetPostByUser(ctx context.Context, arg GetPostByUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostByUser, arg.UserID, arg.Limit)
+type SearchPostsRow struct {
+	ID             uuid.UUID     `json:"id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Title          string        `json:"title"`
+	Url            string        `json:"url"`
+	Description    string        `json:"description"`
+	PublishedAt    time.Time     `json:"published_at"`
+	FeedID         uuid.NullUUID `json:"feed_id"`
+	Author         string        `json:"author"`
+	Rank           float32       `json:"rank"`
+	TitleHighlight string        `json:"title_highlight"`
+	Snippet        string        `json:"snippet"`
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Query,
+		arg.AllFeeds,
+		arg.UserID,
+		arg.MaxResults,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []SearchPostsRow
 	for rows.Next() {
-		var i Post
+		var i SearchPostsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -76,6 +261,10 @@ func (q *Queries) GetPostByUser(ctx context.Context, arg GetPostByUserParams) ([
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Author,
+			&i.Rank,
+			&i.TitleHighlight,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -89,3 +278,20 @@ func (q *Queries) GetPostByUser(ctx context.Context, arg GetPostByUserParams) ([
 	}
 	return items, nil
 }
+
+const setPostArticle = `-- name: SetPostArticle :exec
+UPDATE posts
+  SET article = $1, updated_at = $2
+  WHERE id = $3
+`
+
+type SetPostArticleParams struct {
+	Article   string    `json:"article"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func (q *Queries) SetPostArticle(ctx context.Context, arg SetPostArticleParams) error {
+	_, err := q.db.ExecContext(ctx, setPostArticle, arg.Article, arg.UpdatedAt, arg.ID)
+	return err
+}
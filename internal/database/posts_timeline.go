@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Position in the timeline, posts are ordered by (published_at, id) descending
+type PostsCursor struct {
+	PublishedAt time.Time
+	ID          uuid.UUID
+}
+
+// Zero values leave a filter out, the given filters must all match
+type GetPostsTimelineParams struct {
+	UserID     uuid.UUID
+	FeedIDs    []uuid.UUID
+	FolderID   uuid.NullUUID
+	Author     string
+	Categories []string
+	Read       sql.NullBool
+	Starred    sql.NullBool
+	Before     sql.NullTime
+	After      sql.NullTime
+	Cursor     *PostsCursor
+	Limit      int32
+}
+
+const postColumns = `posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.author, posts.content, posts.guid, posts.comments_url, posts.article`
+
+// The filters of the timeline are optional, so the query is assembled here
+// instead of being generated by sqlc.
+func (q *Queries) GetPostsTimeline(ctx context.Context, arg GetPostsTimelineParams) ([]Post, error) {
+	var args []interface{}
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	userID := param(arg.UserID)
+	var where []string
+	if arg.Starred.Valid && arg.Starred.Bool {
+		// Starred posts stay visible after their feed is unfollowed or removed
+		where = append(where, postStateSet(userID, "starred_at"))
+	} else {
+		where = append(where, `posts.feed_id IN (
+  SELECT feed_follows.feed_id FROM feed_follows
+  WHERE feed_follows.user_id = `+userID+`
+)`)
+	}
+	if arg.Starred.Valid && !arg.Starred.Bool {
+		where = append(where, "NOT "+postStateSet(userID, "starred_at"))
+	}
+	if len(arg.FeedIDs) > 0 {
+		where = append(where, "posts.feed_id = ANY("+param(pq.Array(arg.FeedIDs))+"::uuid[])")
+	}
+	if arg.FolderID.Valid {
+		where = append(where, `posts.feed_id IN (
+  SELECT feed_follows.feed_id FROM feed_follows
+  WHERE feed_follows.user_id = `+userID+`
+    AND feed_follows.folder_id = `+param(arg.FolderID)+`
+)`)
+	}
+	if arg.Author != "" {
+		where = append(where, "lower(posts.author) = lower("+param(arg.Author)+")")
+	}
+	if len(arg.Categories) > 0 {
+		lowered := make([]string, len(arg.Categories))
+		for i, category := range arg.Categories {
+			lowered[i] = strings.ToLower(category)
+		}
+		where = append(where, `EXISTS (
+  SELECT 1 FROM post_categories
+  WHERE post_categories.post_id = posts.id
+    AND lower(post_categories.name) = ANY(`+param(pq.Array(lowered))+`::text[])
+)`)
+	}
+	if arg.Read.Valid {
+		readPost := postStateSet(userID, "read_at")
+		if !arg.Read.Bool {
+			readPost = "NOT " + readPost
+		}
+		where = append(where, readPost)
+	}
+	if arg.Before.Valid {
+		where = append(where, "posts.published_at < "+param(arg.Before.Time))
+	}
+	if arg.After.Valid {
+		where = append(where, "posts.published_at > "+param(arg.After.Time))
+	}
+	if arg.Cursor != nil {
+		where = append(where, fmt.Sprintf("(posts.published_at, posts.id) < (%s, %s)",
+			param(arg.Cursor.PublishedAt), param(arg.Cursor.ID)))
+	}
+
+	query := "SELECT " + postColumns + " FROM posts\nWHERE " +
+		strings.Join(where, "\nAND ") +
+		"\nORDER BY posts.published_at DESC, posts.id DESC\nLIMIT " + param(arg.Limit)
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.Content,
+			&i.Guid,
+			&i.CommentsUrl,
+			&i.Article,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Condition on one of the user's post_states timestamps being set
+func postStateSet(userID string, column string) string {
+	return `EXISTS (
+  SELECT 1 FROM post_states
+  WHERE post_states.post_id = posts.id
+    AND post_states.user_id = ` + userID + `
+    AND post_states.` + column + ` IS NOT NULL
+)`
+}
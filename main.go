@@ -6,7 +6,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"unicode"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 
@@ -16,8 +18,9 @@ import (
 func middlewareCors(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, PATCH, DELETE")
 		w.Header().Set("Access-Control-Allow-Headers", "*")
+		w.Header().Set("Access-Control-Expose-Headers", "Retry-After, X-Request-ID, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -26,6 +29,31 @@ func middlewareCors(next http.Handler) http.Handler {
 	})
 }
 
+// Tags each request with an ID, reusing a well-formed one from the client or
+// proxy, so error responses can be matched with the server logs
+func middlewareRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if c > unicode.MaxASCII || !(unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
@@ -37,23 +65,24 @@ func main() {
 		log.Fatal("Unable to connect to database.")
 	}
 
-	config := apiConfig{database.New(db)}
+	rateLimits, err := rateLimitsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config := apiConfig{
+		DB:         database.New(db),
+		Conn:       db,
+		InviteOnly: os.Getenv("REGISTRATION") == "invite",
+		RateLimits: rateLimits,
+	}
 	ctx := context.Background()
 	config.fetchFeeds(ctx)
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("GET /v1/ok", getHealthCheck)
-	mux.HandleFunc("GET /v1/err", getErrorCheck)
-	mux.HandleFunc("POST /v1/users", config.postCreateUser)
-	mux.HandleFunc("GET /v1/users", config.middlewareAuth(config.getCurrentUser))
-	mux.HandleFunc("POST /v1/feeds", config.middlewareAuth(config.postCreateFeed))
-	mux.HandleFunc("GET /v1/feeds", config.getAllFeeds)
-	mux.HandleFunc("POST /v1/feed_follows", config.middlewareAuth(config.postCreateFeedFollow))
-	mux.HandleFunc("GET /v1/feed_follows", config.middlewareAuth(config.getUserFeedFollows))
-	mux.HandleFunc("DELETE /v1/feed_follows/{ffID}", config.middlewareAuth(config.deleteFeedFollow))
-	mux.HandleFunc("GET /v1/posts", config.middlewareAuth(config.getPostsForUser))
-
-	corsMux := middlewareCors(mux)
+	trustProxyHeaders = os.Getenv("TRUST_PROXY_HEADERS") == "true"
+	mux := config.routes()
+
+	corsMux := middlewareRequestID(middlewareCors(mux))
 	server := &http.Server{Addr: ":" + port, Handler: corsMux}
 	log.Fatal(server.ListenAndServe())
 }
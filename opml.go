@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+const (
+	maxOPMLSize  = 5 << 20
+	maxOPMLFeeds = 500
+)
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    OPMLHead `xml:"head"`
+	Body    OPMLBody `xml:"body"`
+}
+
+type OPMLHead struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+	OwnerName   string `xml:"ownerName,omitempty"`
+}
+
+type OPMLBody struct {
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+type OPMLOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+// Counts the outlines with a feed URL, including those in folders
+func countOPMLFeeds(outlines []OPMLOutline) int {
+	count := 0
+	for _, outline := range outlines {
+		if outline.XMLURL != "" {
+			count++
+		} else {
+			count += countOPMLFeeds(outline.Outlines)
+		}
+	}
+	return count
+}
+
+func (self *OPMLOutline) name() string {
+	if self.Title != "" {
+		return self.Title
+	}
+	return self.Text
+}
+
+type opmlImportResult struct {
+	Title        string     `json:"title"`
+	Url          string     `json:"url"`
+	Folder       string     `json:"folder,omitempty"`
+	Status       string     `json:"status"`
+	Error        string     `json:"error,omitempty"`
+	FeedID       *uuid.UUID `json:"feed_id,omitempty"`
+	FeedFollowID *uuid.UUID `json:"feed_follow_id,omitempty"`
+}
+
+const (
+	opmlCreated = "created"
+	opmlReused  = "reused"
+	opmlFailed  = "failed"
+)
+
+type opmlImporter struct {
+	DB      *database.Queries
+	user    database.User
+	folders map[string]uuid.NullUUID
+	results []opmlImportResult
+	// Records the feeds and follows the import creates
+	audit func(auditEvent)
+}
+
+// Walks the outline tree, outlines without a feed URL are treated as folders.
+// Nested folders are flattened into a single folder named after their path.
+func (self *opmlImporter) importOutlines(ctx context.Context, outlines []OPMLOutline, folder string) {
+	for _, outline := range outlines {
+		if outline.XMLURL == "" {
+			subfolder := outline.name()
+			if folder != "" {
+				subfolder = folder + "/" + subfolder
+			}
+			self.importOutlines(ctx, outline.Outlines, subfolder)
+			continue
+		}
+		self.results = append(self.results, self.importFeed(ctx, outline, folder))
+	}
+}
+
+func (self *opmlImporter) importFeed(ctx context.Context, outline OPMLOutline, folder string) opmlImportResult {
+	result := opmlImportResult{
+		Title:  outline.name(),
+		Url:    outline.XMLURL,
+		Folder: folder,
+		Status: opmlReused,
+	}
+	fail := func(msg string) opmlImportResult {
+		result.Status = opmlFailed
+		result.Error = msg
+		return result
+	}
+
+	if result.Title == "" {
+		result.Title = outline.XMLURL
+	}
+	// The same checks postCreateFeed applies
+	v := validator{}
+	v.url("xmlUrl", outline.XMLURL)
+	v.maxLength("title", result.Title, maxNameLength)
+	v.maxLength("folder", folder, maxNameLength)
+	if len(v.errors) > 0 {
+		return fail(v.errors[0].Field + ": " + v.errors[0].Message)
+	}
+
+	folderID, err := self.folderID(ctx, folder)
+	if err != nil {
+		return fail("Could not create folder")
+	}
+
+	feed, err := self.DB.GetFeedByUrl(ctx, outline.XMLURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		result.Status = opmlCreated
+		feed, err = self.DB.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name:      result.Title,
+			Url:       outline.XMLURL,
+			UserID:    uuid.NullUUID{UUID: self.user.ID, Valid: true},
+		})
+	}
+	if err != nil {
+		return fail("Could not create feed")
+	}
+	result.FeedID = &feed.ID
+	if result.Status == opmlCreated {
+		self.audit(auditEvent{
+			Action:     auditFeedCreate,
+			TargetType: "feed",
+			TargetID:   feed.ID,
+			Details:    map[string]any{"name": feed.Name, "url": feed.Url},
+		})
+	}
+
+	feedFollow, err := self.DB.GetUserFeedFollow(ctx, database.GetUserFeedFollowParams{
+		UserID: self.user.ID,
+		FeedID: feed.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Keep the label from the other reader when it differs from the feed name
+		var title sql.NullString
+		if result.Title != feed.Name {
+			title = sql.NullString{String: result.Title, Valid: true}
+		}
+		feedFollow, err = self.DB.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			FeedID:    feed.ID,
+			UserID:    self.user.ID,
+			Title:     title,
+			FolderID:  folderID,
+		})
+		if err == nil {
+			self.audit(auditEvent{
+				Action:     auditFollowCreate,
+				TargetType: "feed_follow",
+				TargetID:   feedFollow.ID,
+				Details:    map[string]any{"feed_id": feed.ID},
+			})
+		}
+	}
+	if err != nil {
+		return fail("Could not create feed follow")
+	}
+	result.FeedFollowID = &feedFollow.ID
+
+	return result
+}
+
+// Returns the user's folder with the given name, creating it if needed
+func (self *opmlImporter) folderID(ctx context.Context, name string) (uuid.NullUUID, error) {
+	if name == "" {
+		return uuid.NullUUID{}, nil
+	}
+	if folderID, ok := self.folders[name]; ok {
+		return folderID, nil
+	}
+
+	folder, err := self.DB.GetUserFolderByName(ctx, database.GetUserFolderByNameParams{
+		UserID: self.user.ID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		folder, err = self.DB.CreateFolder(ctx, database.CreateFolderParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			Name:      name,
+			UserID:    self.user.ID,
+		})
+	}
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	folderID := uuid.NullUUID{UUID: folder.ID, Valid: true}
+	self.folders[name] = folderID
+	return folderID, nil
+}
+
+func (self *apiConfig) postImportOPML(w http.ResponseWriter, r *http.Request, user database.User) {
+	var opml OPML
+	decoder := xml.NewDecoder(http.MaxBytesReader(w, r.Body, maxOPMLSize))
+	if err := decoder.Decode(&opml); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid OPML document")
+		return
+	}
+	if !strings.HasPrefix(opml.Version, "1.") && !strings.HasPrefix(opml.Version, "2.") {
+		respondWithError(w, http.StatusBadRequest, "Unsupported OPML version")
+		return
+	}
+	if countOPMLFeeds(opml.Body.Outlines) > maxOPMLFeeds {
+		respondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("OPML documents can list at most %d feeds", maxOPMLFeeds))
+		return
+	}
+
+	importer := opmlImporter{
+		DB:      self.DB,
+		user:    user,
+		folders: map[string]uuid.NullUUID{},
+		results: []opmlImportResult{},
+		audit: func(event auditEvent) {
+			self.audit(r, user.ID, event)
+		},
+	}
+	importer.importOutlines(r.Context(), opml.Body.Outlines, "")
+
+	statuses := map[string]int{}
+	for _, result := range importer.results {
+		statuses[result.Status]++
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditOPMLImport,
+		TargetType: "opml",
+		Details:    map[string]any{"outlines": len(importer.results), "statuses": statuses},
+	})
+
+	respondWithJSON(w, http.StatusOK, importer.results)
+}
+
+func (self *apiConfig) getExportOPML(w http.ResponseWriter, r *http.Request, user database.User) {
+	subscriptions, err := self.DB.GetUserSubscriptions(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get subscriptions")
+		return
+	}
+
+	opml := OPML{
+		Version: "2.0",
+		Head: OPMLHead{
+			Title:       "blagg subscriptions of " + user.Name,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+			OwnerName:   user.Name,
+		},
+	}
+
+	// Subscriptions are sorted by folder, unfiled ones first
+	folders := map[string]int{}
+	for _, sub := range subscriptions {
+		title := sub.FeedName
+		if sub.Title.Valid {
+			title = sub.Title.String
+		}
+		outline := OPMLOutline{
+			Text:   title,
+			Title:  title,
+			Type:   "rss",
+			XMLURL: sub.FeedUrl,
+		}
+
+		if !sub.FolderName.Valid {
+			opml.Body.Outlines = append(opml.Body.Outlines, outline)
+			continue
+		}
+		i, ok := folders[sub.FolderName.String]
+		if !ok {
+			i = len(opml.Body.Outlines)
+			folders[sub.FolderName.String] = i
+			opml.Body.Outlines = append(opml.Body.Outlines, OPMLOutline{
+				Text:  sub.FolderName.String,
+				Title: sub.FolderName.String,
+			})
+		}
+		opml.Body.Outlines[i].Outlines = append(opml.Body.Outlines[i].Outlines, outline)
+	}
+
+	data, err := xml.MarshalIndent(opml, "", "  ")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not render OPML")
+		return
+	}
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="blagg.opml"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
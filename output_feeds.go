@@ -0,0 +1,502 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+// Number of posts in the feeds a user publishes
+const outputFeedSize = 50
+
+// Formats of the published timeline, keyed by the extension in the URL
+var outputFeedFormats = []string{"atom", "rss", "json"}
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  AtomPerson  `xml:"author"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+type AtomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type AtomSource struct {
+	ID    string     `xml:"id"`
+	Title string     `xml:"title"`
+	Links []AtomLink `xml:"link"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Authors    []AtomPerson   `xml:"author"`
+	Links      []AtomLink     `xml:"link"`
+	Categories []AtomCategory `xml:"category"`
+	Summary    *AtomText      `xml:"summary"`
+	Content    *AtomText      `xml:"content"`
+	Source     *AtomSource    `xml:"source"`
+}
+
+type RSSFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	SelfLink      RSSLink   `xml:"atom:link"`
+	Items         []RSSItem `xml:"item"`
+}
+
+type RSSLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type RSSGuid struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type RSSSource struct {
+	Url   string `xml:"url,attr"`
+	Value string `xml:",chardata"`
+}
+
+type RSSEnclosure struct {
+	Url    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type RSSItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description"`
+	Guid        RSSGuid       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Comments    string        `xml:"comments,omitempty"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *RSSEnclosure `xml:"enclosure"`
+	Source      *RSSSource    `xml:"source"`
+}
+
+// JSON Feed 1.1, https://www.jsonfeed.org/version/1.1/
+type jsonFeed struct {
+	Version string         `json:"version"`
+	Title   string         `json:"title"`
+	FeedUrl string         `json:"feed_url"`
+	Authors []jsonAuthor   `json:"authors"`
+	Items   []jsonFeedItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	Url  string `json:"url,omitempty"`
+}
+
+type jsonAttachment struct {
+	Url               string `json:"url"`
+	MimeType          string `json:"mime_type"`
+	SizeInBytes       int64  `json:"size_in_bytes,omitempty"`
+	DurationInSeconds int32  `json:"duration_in_seconds,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	Url           string           `json:"url,omitempty"`
+	ExternalUrl   string           `json:"external_url,omitempty"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished time.Time        `json:"date_published"`
+	DateModified  time.Time        `json:"date_modified"`
+	Authors       []jsonAuthor     `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Attachments   []jsonAttachment `json:"attachments,omitempty"`
+}
+
+// A post of the published timeline along with the feed it came from
+type outputFeedEntry struct {
+	post database.JSONPost
+	feed database.Feed
+}
+
+// Posts and feeds use their UUIDs as entry IDs so they stay the same when a
+// post's URL or title changes
+func urnUUID(id uuid.UUID) string {
+	return "urn:uuid:" + id.String()
+}
+
+// The address the request was made to, for links in rendered documents
+func requestBaseURL(r *http.Request) string {
+	return requestScheme(r) + "://" + r.Host
+}
+
+// The scheme the client used, which the trusted proxy passes on when it
+// terminates TLS
+func requestScheme(r *http.Request) string {
+	if trustProxyHeaders && r.Header.Get("X-Forwarded-Proto") != "" {
+		return r.Header.Get("X-Forwarded-Proto")
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// The feed content, falling back to the summary for feeds that only have one
+func (self *outputFeedEntry) html() string {
+	if self.post.Content != "" {
+		return self.post.Content
+	}
+	return self.post.Description
+}
+
+func (self *outputFeedEntry) author() string {
+	if self.post.Author != "" {
+		return self.post.Author
+	}
+	return self.feed.Name
+}
+
+// Creates or replaces the secret token that grants read access to the feeds
+// of the user's timeline. Like API keys it is only shown once.
+func (self *apiConfig) postFeedToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Token string            `json:"token"`
+		Urls  map[string]string `json:"urls"`
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create feed token")
+		return
+	}
+	if _, err := self.DB.UpsertFeedToken(r.Context(), database.UpsertFeedTokenParams{
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC(),
+		TokenHash: hashToken(token),
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create feed token")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditFeedTokenCreate,
+		TargetType: "user",
+		TargetID:   user.ID,
+	})
+
+	urls := map[string]string{}
+	for _, format := range outputFeedFormats {
+		urls[format] = requestBaseURL(r) + "/v1/users/" + user.ID.String() + "/feed." + format + "?token=" + token
+	}
+	respondWithJSON(w, http.StatusOK, response{token, urls})
+}
+
+func (self *apiConfig) deleteFeedToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	count, err := self.DB.DeleteFeedToken(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete feed token")
+		return
+	}
+	if count == 0 {
+		respondWithError(w, http.StatusNotFound, "No feed token to delete")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditFeedTokenDelete,
+		TargetType: "user",
+		TargetID:   user.ID,
+	})
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+// Feed readers cannot send headers, so the published feeds are authenticated
+// by the token in the URL instead of an API key
+func (self *apiConfig) outputFeedUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithInvalidID(w, "user")
+		return database.User{}, false
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, http.StatusUnauthorized, "Feed token is required")
+		return database.User{}, false
+	}
+	user, err := self.DB.GetUserByFeedToken(r.Context(), database.GetUserByFeedTokenParams{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid feed token")
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get feed")
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// The newest posts of the user's followed feeds and when any of them last
+// changed, the user's own update time when there are none
+func (self *apiConfig) outputFeedEntries(ctx context.Context, user database.User) ([]outputFeedEntry, time.Time, error) {
+	posts, err := self.DB.GetPostsTimeline(ctx, database.GetPostsTimelineParams{
+		UserID: user.ID,
+		Limit:  outputFeedSize,
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	jsonPosts, err := self.postsJson(ctx, posts)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var feedIDs []uuid.UUID
+	for _, post := range posts {
+		if post.FeedID.Valid {
+			feedIDs = append(feedIDs, post.FeedID.UUID)
+		}
+	}
+	feeds, err := self.DB.GetFeedsByIDs(ctx, feedIDs)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	feedsByID := make(map[uuid.UUID]database.Feed, len(feeds))
+	for _, feed := range feeds {
+		feedsByID[feed.ID] = feed
+	}
+
+	updated := user.UpdatedAt
+	entries := make([]outputFeedEntry, len(posts))
+	for i, post := range jsonPosts {
+		entries[i] = outputFeedEntry{post, feedsByID[post.FeedID.UUID]}
+		if post.UpdatedAt.After(updated) {
+			updated = post.UpdatedAt
+		}
+	}
+	return entries, updated.UTC(), nil
+}
+
+func (self *apiConfig) getOutputFeedAtom(w http.ResponseWriter, r *http.Request) {
+	user, ok := self.outputFeedUser(w, r)
+	if !ok {
+		return
+	}
+	entries, updated, err := self.outputFeedEntries(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get feed")
+		return
+	}
+
+	feed := AtomFeed{
+		ID:      urnUUID(user.ID),
+		Title:   "blagg timeline of " + user.Name,
+		Updated: updated.Format(time.RFC3339),
+		Author:  AtomPerson{user.Name},
+		Links: []AtomLink{
+			{Href: requestBaseURL(r) + r.URL.RequestURI(), Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: []AtomEntry{},
+	}
+	for _, entry := range entries {
+		atomEntry := AtomEntry{
+			ID:        urnUUID(entry.post.ID),
+			Title:     entry.post.Title,
+			Updated:   entry.post.UpdatedAt.UTC().Format(time.RFC3339),
+			Published: entry.post.PublishedAt.UTC().Format(time.RFC3339),
+			Authors:   []AtomPerson{{entry.author()}},
+			Content:   &AtomText{Type: "html", Body: entry.html()},
+		}
+		if entry.post.Url != "" {
+			atomEntry.Links = append(atomEntry.Links, AtomLink{Href: entry.post.Url, Rel: "alternate"})
+		}
+		if entry.post.CommentsUrl != "" {
+			atomEntry.Links = append(atomEntry.Links, AtomLink{Href: entry.post.CommentsUrl, Rel: "replies"})
+		}
+		for _, enclosure := range entry.post.Enclosures {
+			link := AtomLink{Href: enclosure.Url, Rel: "enclosure", Type: enclosure.Type}
+			if enclosure.Length.Valid {
+				link.Length = strconv.FormatInt(enclosure.Length.Int64, 10)
+			}
+			atomEntry.Links = append(atomEntry.Links, link)
+		}
+		for _, category := range entry.post.Categories {
+			atomEntry.Categories = append(atomEntry.Categories, AtomCategory{category})
+		}
+		if entry.post.Content != "" && entry.post.DescriptionText != "" {
+			atomEntry.Summary = &AtomText{Type: "text", Body: entry.post.DescriptionText}
+		}
+		if entry.feed.ID != uuid.Nil {
+			atomEntry.Source = &AtomSource{
+				ID:    urnUUID(entry.feed.ID),
+				Title: entry.feed.Name,
+				Links: []AtomLink{{Href: entry.feed.Url, Rel: "self"}},
+			}
+		}
+		feed.Entries = append(feed.Entries, atomEntry)
+	}
+
+	respondWithXML(w, "application/atom+xml; charset=utf-8", feed)
+}
+
+func (self *apiConfig) getOutputFeedRSS(w http.ResponseWriter, r *http.Request) {
+	user, ok := self.outputFeedUser(w, r)
+	if !ok {
+		return
+	}
+	entries, updated, err := self.outputFeedEntries(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get feed")
+		return
+	}
+
+	selfURL := requestBaseURL(r) + r.URL.RequestURI()
+	feed := RSSFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: RSSChannel{
+			Title:         "blagg timeline of " + user.Name,
+			Link:          selfURL,
+			Description:   "Posts from the feeds " + user.Name + " follows on blagg",
+			LastBuildDate: updated.Format(time.RFC1123Z),
+			SelfLink:      RSSLink{Href: selfURL, Rel: "self", Type: "application/rss+xml"},
+			Items:         []RSSItem{},
+		},
+	}
+	for _, entry := range entries {
+		item := RSSItem{
+			Title:       entry.post.Title,
+			Link:        entry.post.Url,
+			Description: entry.html(),
+			Guid:        RSSGuid{IsPermaLink: "false", Value: urnUUID(entry.post.ID)},
+			PubDate:     entry.post.PublishedAt.UTC().Format(time.RFC1123Z),
+			Comments:    entry.post.CommentsUrl,
+			Categories:  entry.post.Categories,
+		}
+		// RSS allows a single enclosure per item
+		if len(entry.post.Enclosures) > 0 {
+			enclosure := entry.post.Enclosures[0]
+			item.Enclosure = &RSSEnclosure{Url: enclosure.Url, Length: "0", Type: enclosure.Type}
+			if enclosure.Length.Valid {
+				item.Enclosure.Length = strconv.FormatInt(enclosure.Length.Int64, 10)
+			}
+		}
+		if entry.feed.ID != uuid.Nil {
+			item.Source = &RSSSource{Url: entry.feed.Url, Value: entry.feed.Name}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	respondWithXML(w, "application/rss+xml; charset=utf-8", feed)
+}
+
+func (self *apiConfig) getOutputFeedJSON(w http.ResponseWriter, r *http.Request) {
+	user, ok := self.outputFeedUser(w, r)
+	if !ok {
+		return
+	}
+	entries, _, err := self.outputFeedEntries(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get feed")
+		return
+	}
+
+	feed := jsonFeed{
+		Version: "https://jsonfeed.org/version/1.1",
+		Title:   "blagg timeline of " + user.Name,
+		FeedUrl: requestBaseURL(r) + r.URL.RequestURI(),
+		Authors: []jsonAuthor{{Name: user.Name}},
+		Items:   []jsonFeedItem{},
+	}
+	for _, entry := range entries {
+		item := jsonFeedItem{
+			ID:            urnUUID(entry.post.ID),
+			Url:           entry.post.Url,
+			Title:         entry.post.Title,
+			ContentHTML:   entry.html(),
+			DatePublished: entry.post.PublishedAt.UTC(),
+			DateModified:  entry.post.UpdatedAt.UTC(),
+			Authors:       []jsonAuthor{{Name: entry.author()}},
+			Tags:          entry.post.Categories,
+		}
+		if entry.post.Content != "" {
+			item.Summary = entry.post.DescriptionText
+		}
+		if entry.feed.ID != uuid.Nil {
+			item.Authors[0].Url = entry.feed.Url
+		}
+		for _, enclosure := range entry.post.Enclosures {
+			item.Attachments = append(item.Attachments, jsonAttachment{
+				Url:               enclosure.Url,
+				MimeType:          enclosure.Type,
+				SizeInBytes:       enclosure.Length.Int64,
+				DurationInSeconds: enclosure.Duration.Int32,
+			})
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	data, err := json.Marshal(feed)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not render feed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func respondWithXML(w http.ResponseWriter, contentType string, payload any) {
+	data, err := xml.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not render feed")
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+// Number of posts on a planet page
+const planetPageSize = 50
+
+// Slugs appear in the URL of the public pages
+var planetSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+var planetTemplates = template.Must(template.ParseFS(templateFiles, "templates/*.html"))
+
+type planetEntry struct {
+	Post database.JSONPost
+	Feed database.Feed
+	// Sanitized by postsJson, so it is safe to render as is
+	HTML template.HTML
+}
+
+type planetDay struct {
+	Date    time.Time
+	Entries []planetEntry
+}
+
+func (self *apiConfig) postCreatePlanet(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Slug        string `json:"slug"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	v := validator{}
+	v.required("slug", params.Slug)
+	v.maxLength("slug", params.Slug, maxSlugLength)
+	v.check(params.Slug == "" || planetSlugPattern.MatchString(params.Slug), "slug", "Must be lowercase letters, digits and dashes")
+	v.required("title", params.Title)
+	v.maxLength("title", params.Title, maxNameLength)
+	v.maxLength("description", params.Description, maxDescriptionLength)
+	if v.failed(w) {
+		return
+	}
+
+	planet, err := self.DB.CreatePlanet(r.Context(), database.CreatePlanetParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+		Slug:        params.Slug,
+		Title:       params.Title,
+		Description: params.Description,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "A planet with this slug already exists")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create planet")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditPlanetCreate,
+		TargetType: "planet",
+		TargetID:   planet.ID,
+		Details:    map[string]any{"slug": planet.Slug},
+	})
+	respondWithJSON(w, http.StatusOK, planet)
+}
+
+func (self *apiConfig) getPlanets(w http.ResponseWriter, r *http.Request) {
+	planets, err := self.DB.GetPlanets(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get planets")
+		return
+	}
+	if planets == nil {
+		planets = []database.Planet{}
+	}
+	respondWithJSON(w, http.StatusOK, planets)
+}
+
+func (self *apiConfig) deletePlanet(w http.ResponseWriter, r *http.Request, user database.User) {
+	planetID, err := uuid.Parse(r.PathValue("planetID"))
+	if err != nil {
+		respondWithInvalidID(w, "planet")
+		return
+	}
+	count, err := self.DB.DeletePlanet(r.Context(), planetID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete planet")
+		return
+	}
+	if count == 0 {
+		respondWithError(w, http.StatusNotFound, "Planet not found")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditPlanetDelete,
+		TargetType: "planet",
+		TargetID:   planetID,
+	})
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+// Parses the planet and feed IDs of /v1/planets/{planetID}/feeds/{feedID}
+func planetFeedFromPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	planetID, err := uuid.Parse(r.PathValue("planetID"))
+	if err != nil {
+		respondWithInvalidID(w, "planet")
+		return uuid.Nil, uuid.Nil, false
+	}
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		respondWithInvalidID(w, "feed")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return planetID, feedID, true
+}
+
+func (self *apiConfig) putPlanetFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	planetID, feedID, ok := planetFeedFromPath(w, r)
+	if !ok {
+		return
+	}
+	planet, err := self.DB.GetPlanet(r.Context(), planetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Planet not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not add feed to planet")
+		return
+	}
+
+	err = self.DB.AddPlanetFeed(r.Context(), database.AddPlanetFeedParams{
+		PlanetID:  planet.ID,
+		FeedID:    feedID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if isForeignKeyViolation(err) {
+		respondWithError(w, http.StatusNotFound, "Feed not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not add feed to planet")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditPlanetFeedAdd,
+		TargetType: "planet",
+		TargetID:   planet.ID,
+		Details:    map[string]any{"feed_id": feedID},
+	})
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+func (self *apiConfig) deletePlanetFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	planetID, feedID, ok := planetFeedFromPath(w, r)
+	if !ok {
+		return
+	}
+	count, err := self.DB.RemovePlanetFeed(r.Context(), database.RemovePlanetFeedParams{
+		PlanetID: planetID,
+		FeedID:   feedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not remove feed from planet")
+		return
+	}
+	if count == 0 {
+		respondWithError(w, http.StatusNotFound, "Feed is not part of this planet")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditPlanetFeedRemove,
+		TargetType: "planet",
+		TargetID:   planetID,
+		Details:    map[string]any{"feed_id": feedID},
+	})
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+func (self *apiConfig) planetFromPath(w http.ResponseWriter, r *http.Request) (database.Planet, bool) {
+	planet, err := self.DB.GetPlanetBySlug(r.Context(), r.PathValue("slug"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Planet not found")
+		return database.Planet{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get planet")
+		return database.Planet{}, false
+	}
+	return planet, true
+}
+
+// Renders into a buffer first so a failing template still gets a clean error
+func respondWithHTML(w http.ResponseWriter, name string, data any) {
+	var buf bytes.Buffer
+	if err := planetTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		log.Printf("Error rendering %s: %v", name, err.Error())
+		respondWithError(w, http.StatusInternalServerError, "Could not render page")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// The newest posts of the planet's feeds, grouped by the UTC day they were
+// published on
+func (self *apiConfig) getPlanetPage(w http.ResponseWriter, r *http.Request) {
+	planet, ok := self.planetFromPath(w, r)
+	if !ok {
+		return
+	}
+	posts, err := self.DB.GetPlanetPosts(r.Context(), database.GetPlanetPostsParams{
+		PlanetID: planet.ID,
+		Limit:    planetPageSize,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get posts")
+		return
+	}
+	jsonPosts, err := self.postsJson(r.Context(), posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get posts")
+		return
+	}
+	feeds, err := self.DB.GetPlanetFeeds(r.Context(), planet.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get feeds")
+		return
+	}
+	feedsByID := make(map[uuid.UUID]database.Feed, len(feeds))
+	for _, feed := range feeds {
+		feedsByID[feed.ID] = database.Feed{
+			ID:   feed.ID,
+			Name: feed.Name,
+			Url:  feed.Url,
+		}
+	}
+
+	var days []planetDay
+	for _, post := range jsonPosts {
+		post.PublishedAt = post.PublishedAt.UTC()
+		date := post.PublishedAt.Truncate(24 * time.Hour)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, planetDay{Date: date})
+		}
+		html := post.Content
+		if html == "" {
+			html = post.Description
+		}
+		day := &days[len(days)-1]
+		day.Entries = append(day.Entries, planetEntry{
+			Post: post,
+			Feed: feedsByID[post.FeedID.UUID],
+			HTML: template.HTML(html),
+		})
+	}
+
+	respondWithHTML(w, "planet.html", struct {
+		Planet database.Planet
+		Days   []planetDay
+	}{planet, days})
+}
+
+// Lists the feeds contributing to a planet
+func (self *apiConfig) getPlanetFeedsPage(w http.ResponseWriter, r *http.Request) {
+	planet, ok := self.planetFromPath(w, r)
+	if !ok {
+		return
+	}
+	feeds, err := self.DB.GetPlanetFeeds(r.Context(), planet.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get feeds")
+		return
+	}
+	respondWithHTML(w, "planet_feeds.html", struct {
+		Planet database.Planet
+		Feeds  []database.GetPlanetFeedsRow
+	}{planet, feeds})
+}
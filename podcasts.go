@@ -0,0 +1,151 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+func isMediaType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/")
+}
+
+func isPodcastEpisode(item Item) bool {
+	for _, enclosure := range item.Enclosure {
+		if isMediaType(enclosure.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func podcastEpisodeParams(postID uuid.UUID, item Item) database.CreatePodcastEpisodeParams {
+	params := database.CreatePodcastEpisodeParams{
+		ID:       uuid.New(),
+		PostID:   postID,
+		ImageUrl: strings.TrimSpace(item.ITunesImage.Href),
+	}
+	if episode, err := strconv.Atoi(strings.TrimSpace(item.ITunesEpisode)); err == nil {
+		params.Episode = sql.NullInt32{Int32: int32(episode), Valid: true}
+	}
+	// Only the first transcript is kept
+	if len(item.Transcript) > 0 {
+		params.TranscriptUrl = strings.TrimSpace(item.Transcript[0].URL)
+		params.TranscriptType = item.Transcript[0].Type
+	}
+	return params
+}
+
+func parseEnclosureLength(length string) sql.NullInt64 {
+	size, err := strconv.ParseInt(strings.TrimSpace(length), 10, 64)
+	if err != nil || size <= 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: size, Valid: true}
+}
+
+// itunes:duration is either a number of seconds or [HH:]MM:SS
+func parseITunesDuration(duration string) sql.NullInt32 {
+	duration = strings.TrimSpace(duration)
+	if duration == "" {
+		return sql.NullInt32{}
+	}
+
+	seconds := 0.0
+	for _, part := range strings.Split(duration, ":") {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return sql.NullInt32{}
+		}
+		seconds = seconds*60 + value
+	}
+	// Also rejects NaN and infinities, which ParseFloat accepts
+	if !(seconds <= math.MaxInt32) {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(seconds), Valid: true}
+}
+
+func (self *apiConfig) getPodcastEpisodes(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit, err := limitFromQuery(r)
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	episodes, err := self.DB.GetUserPodcastEpisodes(r.Context(), database.GetUserPodcastEpisodesParams{
+		UserID: user.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get podcast episodes")
+		return
+	}
+
+	jsonEpisodes := make([]database.JSONEpisode, len(episodes))
+	postIDs := make([]uuid.UUID, len(episodes))
+	index := make(map[uuid.UUID]int, len(episodes))
+	for i := 0; i < len(episodes); i++ {
+		jsonEpisodes[i] = episodes[i].Json()
+		jsonEpisodes[i].Description = sanitizeHTML(episodes[i].Description, episodes[i].Url)
+		jsonEpisodes[i].DescriptionText = htmlToText(episodes[i].Description)
+		postIDs[i] = episodes[i].ID
+		index[episodes[i].ID] = i
+	}
+
+	enclosures, err := self.DB.GetPostsEnclosures(r.Context(), postIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not get podcast episodes")
+		return
+	}
+	for _, enclosure := range enclosures {
+		i := index[enclosure.PostID]
+		jsonEpisodes[i].Enclosures = append(jsonEpisodes[i].Enclosures, enclosure.Json())
+	}
+
+	respondWithJSON(w, http.StatusOK, jsonEpisodes)
+}
+
+func (self *apiConfig) putPlaybackPosition(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Position int32 `json:"position"`
+	}
+
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondWithInvalidID(w, "post")
+		return
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	if params.Position < 0 {
+		respondWithFieldError(w, "position", "Position must not be negative")
+		return
+	}
+
+	updated, err := self.DB.SetPlaybackPosition(r.Context(), database.SetPlaybackPositionParams{
+		UpdatedAt:        time.Now().UTC(),
+		PlaybackPosition: params.Position,
+		UserID:           user.ID,
+		PostID:           postID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not save playback position")
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusNotFound, "Podcast episode not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, "")
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+func (self *apiConfig) putPostRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	self.setPostReadAt(w, r, user, sql.NullTime{Valid: true, Time: time.Now().UTC()})
+}
+
+func (self *apiConfig) deletePostRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	self.setPostReadAt(w, r, user, sql.NullTime{})
+}
+
+func (self *apiConfig) setPostReadAt(w http.ResponseWriter, r *http.Request, user database.User, readAt sql.NullTime) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondWithInvalidID(w, "post")
+		return
+	}
+
+	updated, err := self.DB.SetPostsReadAt(r.Context(), database.SetPostsReadAtParams{
+		UpdatedAt: time.Now().UTC(),
+		ReadAt:    readAt,
+		UserID:    user.ID,
+		PostIds:   []uuid.UUID{postID},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update post state")
+		return
+	}
+	if updated == 0 {
+		respondWithError(w, http.StatusNotFound, "Post not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+func (self *apiConfig) postMarkPostsRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	self.markPosts(w, r, user, sql.NullTime{Valid: true, Time: time.Now().UTC()})
+}
+
+func (self *apiConfig) postMarkPostsUnread(w http.ResponseWriter, r *http.Request, user database.User) {
+	self.markPosts(w, r, user, sql.NullTime{})
+}
+
+// Bulk update of the read state, every given criteria must match.
+// An empty body, like {}, marks all of the user's posts.
+func (self *apiConfig) markPosts(w http.ResponseWriter, r *http.Request, user database.User, readAt sql.NullTime) {
+	type parameters struct {
+		PostIDs   []uuid.UUID `json:"post_ids"`
+		FeedID    *uuid.UUID  `json:"feed_id"`
+		FolderID  *uuid.UUID  `json:"folder_id"`
+		OlderThan *time.Time  `json:"older_than"`
+	}
+	type response struct {
+		Updated int64 `json:"updated"`
+	}
+
+	params := parameters{}
+	if r.ContentLength != 0 && !decodeBody(w, r, &params) {
+		return
+	}
+
+	arg := database.SetPostsReadAtParams{
+		UpdatedAt: time.Now().UTC(),
+		ReadAt:    readAt,
+		UserID:    user.ID,
+		PostIds:   params.PostIDs,
+	}
+	if params.FeedID != nil {
+		arg.FeedID = uuid.NullUUID{UUID: *params.FeedID, Valid: true}
+	}
+	if params.FolderID != nil {
+		arg.FolderID = uuid.NullUUID{UUID: *params.FolderID, Valid: true}
+	}
+	if params.OlderThan != nil {
+		arg.OlderThan = sql.NullTime{Time: params.OlderThan.UTC(), Valid: true}
+	}
+
+	updated, err := self.DB.SetPostsReadAt(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update post states")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response{updated})
+}
+
+func (self *apiConfig) putPostStar(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondWithInvalidID(w, "post")
+		return
+	}
+
+	starred, err := self.DB.StarPost(r.Context(), database.StarPostParams{
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		PostID:    postID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not star post")
+		return
+	}
+	if starred == 0 {
+		respondWithError(w, http.StatusNotFound, "Post not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, "")
+}
+
+// Unstarring also works for posts whose feed has since been removed
+func (self *apiConfig) deletePostStar(w http.ResponseWriter, r *http.Request, user database.User) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondWithInvalidID(w, "post")
+		return
+	}
+
+	unstarred, err := self.DB.UnstarPost(r.Context(), database.UnstarPostParams{
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		PostID:    postID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not unstar post")
+		return
+	}
+	if unstarred == 0 {
+		respondWithError(w, http.StatusNotFound, "Starred post not found")
+		return
+	}
+	// Posts of removed feeds were only kept for their stars
+	if err := self.DB.DeleteUnstarredOrphanPost(r.Context(), postID); err != nil {
+		log.Printf("Error deleting unstarred orphan post: %v", err.Error())
+	}
+	respondWithJSON(w, http.StatusOK, "")
+}
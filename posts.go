@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+// Converts posts for the API, along with their categories and enclosures.
+// The stored HTML is kept as fetched and only sanitized here.
+func (self *apiConfig) postsJson(ctx context.Context, posts []database.Post) ([]database.JSONPost, error) {
+	jsonPosts := make([]database.JSONPost, len(posts))
+	postIDs := make([]uuid.UUID, len(posts))
+	index := make(map[uuid.UUID]int, len(posts))
+	for i := 0; i < len(posts); i++ {
+		jsonPosts[i] = posts[i].Json()
+		jsonPosts[i].Description = sanitizeHTML(posts[i].Description, posts[i].Url)
+		jsonPosts[i].Content = sanitizeHTML(posts[i].Content, posts[i].Url)
+		jsonPosts[i].DescriptionText = htmlToText(posts[i].Description)
+		jsonPosts[i].Article = sanitizeHTML(posts[i].Article, posts[i].Url)
+		jsonPosts[i].ContentText = htmlToText(posts[i].Content)
+		jsonPosts[i].ArticleText = htmlToText(posts[i].Article)
+		postIDs[i] = posts[i].ID
+		index[posts[i].ID] = i
+	}
+	if len(posts) == 0 {
+		return jsonPosts, nil
+	}
+
+	categories, err := self.DB.GetPostsCategories(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		i := index[category.PostID]
+		jsonPosts[i].Categories = append(jsonPosts[i].Categories, category.Name)
+	}
+
+	enclosures, err := self.DB.GetPostsEnclosures(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, enclosure := range enclosures {
+		i := index[enclosure.PostID]
+		jsonPosts[i].Enclosures = append(jsonPosts[i].Enclosures, enclosure.Json())
+	}
+
+	return jsonPosts, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+const (
+	defaultPostsLimit = 5
+	maxPostsLimit     = 100
+)
+
+// Cursors are opaque to clients, they encode the last post of a page
+func encodeCursor(post database.Post) string {
+	raw := post.PublishedAt.UTC().Format(time.RFC3339Nano) + "|" + post.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*database.PostsCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+	publishedStr, idStr, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, errors.New("Invalid cursor")
+	}
+	publishedAt, err := time.Parse(time.RFC3339Nano, publishedStr)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+	return &database.PostsCursor{PublishedAt: publishedAt, ID: id}, nil
+}
+
+// Limits above the maximum are capped instead of rejected
+func limitFromQuery(r *http.Request) (int32, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return defaultPostsLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("Invalid limit: %q", limitStr)
+	}
+	return int32(min(limit, maxPostsLimit)), nil
+}
+
+// Parses an optional RFC 3339 timestamp query parameter
+func timeFromQuery(r *http.Request, key string) (sql.NullTime, error) {
+	timeStr := r.URL.Query().Get(key)
+	if timeStr == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, timeStr)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("Invalid %s: %q", key, timeStr)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+// Parses an optional boolean query parameter
+func boolFromQuery(r *http.Request, key string) (sql.NullBool, error) {
+	boolStr := r.URL.Query().Get(key)
+	if boolStr == "" {
+		return sql.NullBool{}, nil
+	}
+	b, err := strconv.ParseBool(boolStr)
+	if err != nil {
+		return sql.NullBool{}, fmt.Errorf("Invalid %s: %q", key, boolStr)
+	}
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
+// Parses a query parameter that may be repeated, e.g. ?feed_id=a&feed_id=b
+func uuidsFromQuery(r *http.Request, key string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, idStr := range r.URL.Query()[key] {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s: %q", key, idStr)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
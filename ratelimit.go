@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"blagg/internal/database"
+)
+
+// Idle buckets are full again after this long and can be forgotten
+const rateLimitSweepInterval = 10 * time.Minute
+
+// Only trust X-Forwarded-For when running behind a proxy that sets it
+var trustProxyHeaders = false
+
+// Requests per minute and burst size of a group of routes
+type rateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Used for the groups not set in the environment. auth_failures counts the
+// requests of a client IP that were rejected as unauthenticated.
+var defaultRateLimits = map[string]rateLimit{
+	"public":        {10, 10},
+	"read":          {120, 60},
+	"write":         {60, 30},
+	"fetch":         {10, 5},
+	"auth_failures": {10, 10},
+}
+
+// Reads overrides like RATE_LIMIT_READ=120,60 (per minute, burst) for each
+// group of routes
+func rateLimitsFromEnv() (map[string]rateLimit, error) {
+	limits := map[string]rateLimit{}
+	for group, limit := range defaultRateLimits {
+		name := "RATE_LIMIT_" + strings.ToUpper(group)
+		value := os.Getenv(name)
+		if value != "" {
+			perMinute, burst, _ := strings.Cut(value, ",")
+			var err1, err2 error
+			limit.PerMinute, err1 = strconv.Atoi(strings.TrimSpace(perMinute))
+			limit.Burst, err2 = strconv.Atoi(strings.TrimSpace(burst))
+			if err1 != nil || err2 != nil || limit.PerMinute <= 0 || limit.Burst <= 0 {
+				return nil, fmt.Errorf("%s must be two positive numbers, requests per minute and burst: %q", name, value)
+			}
+		}
+		limits[group] = limit
+	}
+	return limits, nil
+}
+
+// A token bucket per client: burst requests at once, refilled at perMinute
+type rateLimiter struct {
+	mu        sync.Mutex
+	perSecond float64
+	burst     float64
+	buckets   map[string]*rateBucket
+	lastSweep time.Time
+}
+
+type rateBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newRateLimiter(perMinute int, burst int) *rateLimiter {
+	return &rateLimiter{
+		perSecond: float64(perMinute) / 60,
+		burst:     float64(burst),
+		buckets:   map[string]*rateBucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// Takes a token for key, returning the tokens left and, when none was
+// available, how long until the next one
+func (self *rateLimiter) take(key string, now time.Time) (float64, time.Duration, bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if now.Sub(self.lastSweep) > rateLimitSweepInterval {
+		for k, b := range self.buckets {
+			if self.refill(b, now) >= self.burst {
+				delete(self.buckets, k)
+			}
+		}
+		self.lastSweep = now
+	}
+
+	b, ok := self.buckets[key]
+	if !ok {
+		b = &rateBucket{tokens: self.burst, updated: now}
+		self.buckets[key] = b
+	}
+	tokens := self.refill(b, now)
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / self.perSecond * float64(time.Second))
+		return tokens, wait, false
+	}
+	b.tokens--
+	return b.tokens, 0, true
+}
+
+// Reports how long until key has a token again, without taking one
+func (self *rateLimiter) blocked(key string, now time.Time) (time.Duration, bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	b, ok := self.buckets[key]
+	if !ok {
+		return 0, false
+	}
+	tokens := self.refill(b, now)
+	if tokens >= 1 {
+		return 0, false
+	}
+	return time.Duration((1 - tokens) / self.perSecond * float64(time.Second)), true
+}
+
+func (self *rateLimiter) refill(b *rateBucket, now time.Time) float64 {
+	b.tokens = math.Min(self.burst, b.tokens+now.Sub(b.updated).Seconds()*self.perSecond)
+	b.updated = now
+	return b.tokens
+}
+
+// Sets the X-RateLimit-* headers and answers 429 once the bucket is empty
+func (self *rateLimiter) allow(w http.ResponseWriter, key string) bool {
+	tokens, wait, ok := self.take(key, time.Now())
+	untilFull := time.Duration((self.burst - tokens) / self.perSecond * float64(time.Second))
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(int(self.burst)))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(tokens)))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(untilFull.Seconds()))))
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+	}
+	return ok
+}
+
+// Limits authenticated routes per API key, or per session for the browser app
+func (self *rateLimiter) authed(next authedHandler) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user database.User) {
+		key := "user:" + user.ID.String()
+		if keyID, ok := r.Context().Value(apiKeyIDContextKey).(uuid.UUID); ok {
+			key = "key:" + keyID.String()
+		} else if sessionID, ok := r.Context().Value(sessionIDContextKey).(uuid.UUID); ok {
+			key = "session:" + sessionID.String()
+		}
+		if self.allow(w, key) {
+			next(w, r, user)
+		}
+	}
+}
+
+// Limits unauthenticated routes per client IP
+func (self *rateLimiter) public(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if self.allow(w, "ip:"+clientIP(r)) {
+			next(w, r)
+		}
+	}
+}
+
+// Records the status written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (self *statusRecorder) WriteHeader(status int) {
+	self.status = status
+	self.ResponseWriter.WriteHeader(status)
+}
+
+func (self *statusRecorder) Unwrap() http.ResponseWriter {
+	return self.ResponseWriter
+}
+
+// Limits failed authentication per client IP. Only answers of 401 use up a
+// token, once they are gone the IP is turned away before its credentials are
+// looked up.
+func (self *rateLimiter) failures(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := "ip:" + clientIP(r)
+		if wait, blocked := self.blocked(key, time.Now()); blocked {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			respondWithError(w, http.StatusTooManyRequests, "Too many failed authentication attempts")
+			return
+		}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+		if recorder.status == http.StatusUnauthorized {
+			self.take(key, time.Now())
+		}
+	}
+}
+
+// Behind the trusted proxy the client is the last X-Forwarded-For entry, the
+// one the proxy appended. Entries before it come from the client itself.
+func clientIP(r *http.Request) string {
+	if trustProxyHeaders {
+		forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		ip := strings.TrimSpace(forwarded[len(forwarded)-1])
+		if net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		trust     bool
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"remote address", false, "203.0.113.7:4000", nil, "203.0.113.7"},
+		{"ipv6 remote address", false, "[2001:db8::1]:4000", nil, "2001:db8::1"},
+		{"ignores forwarded when not trusted", false, "203.0.113.7:4000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"forwarded by the proxy", true, "10.0.0.2:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"client supplied entries", true, "10.0.0.2:4000", []string{"1.2.3.4, 5.6.7.8, 198.51.100.1"}, "198.51.100.1"},
+		{"several headers", true, "10.0.0.2:4000", []string{"1.2.3.4", "198.51.100.1"}, "198.51.100.1"},
+		{"ipv6 forwarded", true, "10.0.0.2:4000", []string{"2001:db8::2"}, "2001:db8::2"},
+		{"not an address", true, "10.0.0.2:4000", []string{"198.51.100.1, nonsense"}, "10.0.0.2"},
+		{"empty last entry", true, "10.0.0.2:4000", []string{"198.51.100.1,"}, "10.0.0.2"},
+		{"no forwarded header", true, "10.0.0.2:4000", nil, "10.0.0.2"},
+	}
+	t.Cleanup(func() { trustProxyHeaders = false })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustProxyHeaders = tt.trust
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	limiter := newRateLimiter(60, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if _, _, ok := limiter.take("a", now); !ok {
+			t.Fatalf("take %d was refused within the burst", i+1)
+		}
+	}
+	_, wait, ok := limiter.take("a", now)
+	if ok {
+		t.Fatal("take beyond the burst was allowed")
+	}
+	if wait != time.Second {
+		t.Errorf("wait = %v, want 1s", wait)
+	}
+	if _, _, ok := limiter.take("b", now); !ok {
+		t.Error("an empty bucket limited another key")
+	}
+	if _, _, ok := limiter.take("a", now.Add(time.Second)); !ok {
+		t.Error("bucket was not refilled after a second")
+	}
+}
+
+func TestRateLimiterBlocked(t *testing.T) {
+	limiter := newRateLimiter(60, 1)
+	now := time.Now()
+
+	if _, blocked := limiter.blocked("a", now); blocked {
+		t.Error("unknown key is blocked")
+	}
+	limiter.take("a", now)
+	wait, blocked := limiter.blocked("a", now)
+	if !blocked || wait != time.Second {
+		t.Errorf("blocked() = %v, %v, want 1s, true", wait, blocked)
+	}
+	// Looking does not take a token
+	if _, blocked := limiter.blocked("a", now.Add(time.Second)); blocked {
+		t.Error("key is still blocked after refilling")
+	}
+}
+
+func TestRateLimiterFailures(t *testing.T) {
+	limiter := newRateLimiter(1, 2)
+	calls := 0
+	handler := limiter.failures(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "ApiKey good" {
+			respondWithError(w, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+		respondWithJSON(w, http.StatusOK, "")
+	})
+	request := func(remote string, authorization string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remote
+		r.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	for i := 0; i < 5; i++ {
+		if w := request("203.0.113.7:4000", "ApiKey good"); w.Code != http.StatusOK {
+			t.Fatalf("successful request %d got %d", i+1, w.Code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if w := request("203.0.113.7:4000", "ApiKey bad"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("failed request %d got %d, want 401", i+1, w.Code)
+		}
+	}
+
+	calls = 0
+	w := request("203.0.113.7:4000", "ApiKey good")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("got %d after the failures were used up, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("429 has no Retry-After")
+	}
+	if calls != 0 {
+		t.Error("credentials were checked for a blocked IP")
+	}
+	if w := request("198.51.100.1:4000", "ApiKey bad"); w.Code != http.StatusUnauthorized {
+		t.Errorf("another IP got %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import "net/http"
+
+// A ServeMux that remembers its patterns so they can be checked against the
+// OpenAPI document
+type router struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (self *router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	self.patterns = append(self.patterns, pattern)
+	self.ServeMux.HandleFunc(pattern, handler)
+}
+
+// Collects what a handler writes without sending it
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (self *discardWriter) Header() http.Header         { return self.header }
+func (self *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (self *discardWriter) WriteHeader(status int)      { self.status = status }
+
+// Answers requests without a matching route with the usual error envelope
+// instead of the plain text of ServeMux
+func (self *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, pattern := self.ServeMux.Handler(r)
+	if pattern != "" {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	// The handler for unmatched requests is ServeMux's own, it either answers
+	// 404 or 405 or redirects to a cleaned up path
+	discard := &discardWriter{header: http.Header{}, status: http.StatusOK}
+	handler.ServeHTTP(discard, r)
+	switch discard.status {
+	case http.StatusNotFound:
+		respondWithError(w, http.StatusNotFound, "No route matches "+r.URL.Path)
+	case http.StatusMethodNotAllowed:
+		w.Header().Set("Allow", discard.header.Get("Allow"))
+		respondWithError(w, http.StatusMethodNotAllowed, "Method "+r.Method+" is not allowed on "+r.URL.Path)
+	default:
+		for key, values := range discard.header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(discard.status)
+	}
+}
+
+func (self *apiConfig) routes() *router {
+	limiter := func(group string) *rateLimiter {
+		limit, ok := self.RateLimits[group]
+		if !ok {
+			limit = defaultRateLimits[group]
+		}
+		return newRateLimiter(limit.PerMinute, limit.Burst)
+	}
+	publicLimit := limiter("public")
+	readLimit := limiter("read")
+	writeLimit := limiter("write")
+	fetchLimit := limiter("fetch")
+	authFailureLimit := limiter("auth_failures")
+
+	// Failed authentication is limited per client IP before the route's own
+	// limit, which only applies once the caller is known
+	authed := func(scope string, limit *rateLimiter, next authedHandler) http.HandlerFunc {
+		return authFailureLimit.failures(self.middlewareAuth(scope, limit.authed(next)))
+	}
+
+	mux := &router{ServeMux: http.NewServeMux()}
+	mux.HandleFunc("GET /v1/ok", getHealthCheck)
+	mux.HandleFunc("GET /v1/err", getErrorCheck)
+	mux.HandleFunc("GET /v1/openapi.json", getOpenAPISpec)
+	mux.HandleFunc("POST /v1/users", publicLimit.public(self.postCreateUser))
+	mux.HandleFunc("GET /v1/users", authed("", readLimit, self.getCurrentUser))
+	mux.HandleFunc("PATCH /v1/users", authed(scopeAdmin, writeLimit, self.patchCurrentUser))
+	mux.HandleFunc("DELETE /v1/users", authed(scopeAdmin, writeLimit, self.deleteCurrentUser))
+	mux.HandleFunc("PUT /v1/users/password", authed(scopeAdmin, writeLimit, self.putUserPassword))
+	mux.HandleFunc("POST /v1/login", authFailureLimit.failures(publicLimit.public(self.postLogin)))
+	mux.HandleFunc("POST /v1/logout", authed("", writeLimit, self.postLogout))
+	mux.HandleFunc("POST /v1/users/api_key/rotate", authed(scopeAdmin, writeLimit, self.postRotateApiKey))
+	mux.HandleFunc("POST /v1/api_keys", authed(scopeAdmin, writeLimit, self.postCreateApiKey))
+	mux.HandleFunc("GET /v1/api_keys", authed(scopeAdmin, readLimit, self.getUserApiKeys))
+	mux.HandleFunc("DELETE /v1/api_keys/{keyID}", authed(scopeAdmin, writeLimit, self.deleteApiKey))
+	mux.HandleFunc("POST /v1/users/feed_token", authed(scopeAdmin, writeLimit, self.postFeedToken))
+	mux.HandleFunc("DELETE /v1/users/feed_token", authed(scopeAdmin, writeLimit, self.deleteFeedToken))
+	mux.HandleFunc("GET /v1/users/{userID}/feed.atom", authFailureLimit.failures(readLimit.public(self.getOutputFeedAtom)))
+	mux.HandleFunc("GET /v1/users/{userID}/feed.rss", authFailureLimit.failures(readLimit.public(self.getOutputFeedRSS)))
+	mux.HandleFunc("GET /v1/users/{userID}/feed.json", authFailureLimit.failures(readLimit.public(self.getOutputFeedJSON)))
+	mux.HandleFunc("GET /v1/admin/users", authed(scopeAdmin, readLimit, self.middlewareAdmin(self.getAdminUsers)))
+	mux.HandleFunc("PUT /v1/admin/users/{userID}", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.putAdminUser)))
+	mux.HandleFunc("DELETE /v1/admin/users/{userID}", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.deleteAdminUser)))
+	mux.HandleFunc("POST /v1/admin/users/{userID}/api_keys/reset", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.postAdminResetApiKeys)))
+	mux.HandleFunc("POST /v1/admin/invites", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.postCreateInviteCode)))
+	mux.HandleFunc("GET /v1/admin/invites", authed(scopeAdmin, readLimit, self.middlewareAdmin(self.getInviteCodes)))
+	mux.HandleFunc("DELETE /v1/admin/invites/{inviteID}", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.deleteInviteCode)))
+	mux.HandleFunc("GET /v1/audit", authed(scopeAdmin, readLimit, self.getAuditEvents))
+	mux.HandleFunc("POST /v1/feeds", authed(scopeFeedsWrite, fetchLimit, self.postCreateFeed))
+	mux.HandleFunc("GET /v1/feeds", readLimit.public(self.getAllFeeds))
+	mux.HandleFunc("POST /v1/feed_follows", authed(scopeFollowsWrite, writeLimit, self.postCreateFeedFollow))
+	mux.HandleFunc("GET /v1/feed_follows", authed(scopeFollowsRead, readLimit, self.getUserFeedFollows))
+	mux.HandleFunc("PUT /v1/feed_follows/{ffID}", authed(scopeFollowsWrite, writeLimit, self.putFeedFollow))
+	mux.HandleFunc("PUT /v1/feed_follows/{ffID}/full_text", authed(scopeFollowsWrite, fetchLimit, self.putFeedFollowFullText))
+	mux.HandleFunc("DELETE /v1/feed_follows/{ffID}", authed(scopeFollowsWrite, writeLimit, self.deleteFeedFollow))
+	mux.HandleFunc("POST /v1/opml", authed(scopeFollowsWrite, fetchLimit, self.postImportOPML))
+	mux.HandleFunc("GET /v1/opml", authed(scopeFollowsRead, readLimit, self.getExportOPML))
+	mux.HandleFunc("POST /v1/folders", authed(scopeFollowsWrite, writeLimit, self.postCreateFolder))
+	mux.HandleFunc("GET /v1/folders", authed(scopeFollowsRead, readLimit, self.getUserFolders))
+	mux.HandleFunc("PUT /v1/folders/{folderID}", authed(scopeFollowsWrite, writeLimit, self.putRenameFolder))
+	mux.HandleFunc("DELETE /v1/folders/{folderID}", authed(scopeFollowsWrite, writeLimit, self.deleteFolder))
+	mux.HandleFunc("GET /v1/posts", authed(scopePostsRead, readLimit, self.getPostsForUser))
+	mux.HandleFunc("GET /v1/posts/search", authed(scopePostsRead, readLimit, self.getSearchPosts))
+	mux.HandleFunc("POST /v1/posts/read", authed(scopePostsWrite, writeLimit, self.postMarkPostsRead))
+	mux.HandleFunc("POST /v1/posts/unread", authed(scopePostsWrite, writeLimit, self.postMarkPostsUnread))
+	mux.HandleFunc("PUT /v1/posts/{postID}/read", authed(scopePostsWrite, writeLimit, self.putPostRead))
+	mux.HandleFunc("DELETE /v1/posts/{postID}/read", authed(scopePostsWrite, writeLimit, self.deletePostRead))
+	mux.HandleFunc("PUT /v1/posts/{postID}/star", authed(scopePostsWrite, writeLimit, self.putPostStar))
+	mux.HandleFunc("DELETE /v1/posts/{postID}/star", authed(scopePostsWrite, writeLimit, self.deletePostStar))
+	mux.HandleFunc("PUT /v1/posts/{postID}/playback", authed(scopePostsWrite, writeLimit, self.putPlaybackPosition))
+	mux.HandleFunc("GET /v1/podcasts", authed(scopePostsRead, readLimit, self.getPodcastEpisodes))
+	mux.HandleFunc("POST /v1/planets", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.postCreatePlanet)))
+	mux.HandleFunc("GET /v1/planets", readLimit.public(self.getPlanets))
+	mux.HandleFunc("DELETE /v1/planets/{planetID}", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.deletePlanet)))
+	mux.HandleFunc("PUT /v1/planets/{planetID}/feeds/{feedID}", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.putPlanetFeed)))
+	mux.HandleFunc("DELETE /v1/planets/{planetID}/feeds/{feedID}", authed(scopeAdmin, writeLimit, self.middlewareAdmin(self.deletePlanetFeed)))
+	mux.HandleFunc("GET /planet/{slug}", readLimit.public(self.getPlanetPage))
+	mux.HandleFunc("GET /planet/{slug}/feeds", readLimit.public(self.getPlanetFeedsPage))
+	return mux
+}
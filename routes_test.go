@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Every registered route has to be described in openapi.json and the
+// document must not describe routes that do not exist
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.1") {
+		t.Errorf("openapi.json has version %q, want 3.1", spec.OpenAPI)
+	}
+
+	described := map[string]bool{}
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			described[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	registered := map[string]bool{}
+	for _, pattern := range (&apiConfig{}).routes().patterns {
+		registered[pattern] = true
+		if !described[pattern] {
+			t.Errorf("route %s is missing from openapi.json", pattern)
+		}
+	}
+
+	var stale []string
+	for pattern := range described {
+		if !registered[pattern] {
+			stale = append(stale, pattern)
+		}
+	}
+	sort.Strings(stale)
+	for _, pattern := range stale {
+		t.Errorf("openapi.json describes %s which is not registered", pattern)
+	}
+}
@@ -11,6 +11,16 @@ type Channel struct {
 }
 
 type ItemEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type ItemImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type ItemTranscript struct {
 	URL  string `xml:"url,attr"`
 	Type string `xml:"type,attr"`
 }
@@ -25,6 +35,12 @@ type Item struct {
 	Enclosure   []ItemEnclosure `xml:"enclosure"`
 	Description string          `xml:"description"`
 	Author      string          `xml:"author"`
-	Content     string          `xml:"content"`
+	Creator     string          `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content     string          `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
 	FullText    string          `xml:"full-text"`
+
+	ITunesDuration string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesEpisode  string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesImage    ItemImage        `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	Transcript     []ItemTranscript `xml:"https://podcastindex.org/namespace/1.0 transcript"`
 }
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements kept by the sanitizer, along with the attributes they may carry
+var allowedElements = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.Audio:      {"src", "controls"},
+	atom.B:          nil,
+	atom.Blockquote: {"cite"},
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Kbd:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         {"start"},
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite"},
+	atom.S:          nil,
+	atom.Small:      nil,
+	atom.Source:     {"src", "type"},
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+	atom.Video:      {"src", "poster", "controls", "width", "height"},
+}
+
+// Elements dropped together with everything inside them. This includes the
+// raw text elements, whose content the tokenizer does not parse as markup.
+var droppedElements = map[atom.Atom]bool{
+	atom.Embed:     true,
+	atom.Form:      true,
+	atom.Head:      true,
+	atom.Iframe:    true,
+	atom.Math:      true,
+	atom.Noembed:   true,
+	atom.Noframes:  true,
+	atom.Noscript:  true,
+	atom.Object:    true,
+	atom.Plaintext: true,
+	atom.Script:    true,
+	atom.Style:     true,
+	atom.Svg:       true,
+	atom.Template:  true,
+	atom.Textarea:  true,
+	atom.Title:     true,
+	atom.Xmp:       true,
+}
+
+// Elements that start a new line when rendered as plain text
+var blockElements = map[atom.Atom]bool{
+	atom.Blockquote: true,
+	atom.Br:         true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Hr:         true,
+	atom.Li:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Tr:         true,
+}
+
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"cite":   true,
+	"poster": true,
+}
+
+// Resolves a link against the post URL, only web and mail links are kept
+func sanitizeURL(link string, base *url.URL) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return u.String(), true
+	}
+	return "", false
+}
+
+// Rewrites feed provided HTML keeping only allowlisted elements and
+// attributes. Relative links are resolved against baseURL.
+func sanitizeHTML(raw string, baseURL string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil || !base.IsAbs() {
+		base = nil
+	}
+
+	var out strings.Builder
+	var open []atom.Atom
+	dropping := 0
+	tokenizer := html.NewTokenizer(strings.NewReader(raw))
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedElements[token.DataAtom] {
+				if tokenType == html.StartTagToken && !isVoidElement(token.DataAtom) {
+					dropping++
+				}
+				continue
+			}
+			allowedAttrs, ok := allowedElements[token.DataAtom]
+			if dropping > 0 || !ok {
+				continue
+			}
+
+			out.WriteString("<" + token.Data)
+			for _, attr := range token.Attr {
+				if attr.Namespace != "" || !slices.Contains(allowedAttrs, attr.Key) {
+					continue
+				}
+				value := attr.Val
+				if urlAttributes[attr.Key] {
+					if value, ok = sanitizeURL(value, base); !ok {
+						continue
+					}
+					if attr.Key != "href" && strings.HasPrefix(value, "mailto:") {
+						continue
+					}
+				}
+				out.WriteString(" " + attr.Key + `="` + html.EscapeString(value) + `"`)
+			}
+			if token.DataAtom == atom.A {
+				out.WriteString(` rel="nofollow noopener noreferrer"`)
+			}
+			out.WriteString(">")
+
+			if tokenType == html.StartTagToken && !isVoidElement(token.DataAtom) {
+				open = append(open, token.DataAtom)
+			}
+
+		case html.EndTagToken:
+			if droppedElements[token.DataAtom] {
+				if dropping > 0 {
+					dropping--
+				}
+				continue
+			}
+			if dropping > 0 {
+				continue
+			}
+			// Close everything up to the matching element, ignore stray end tags
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.DataAtom {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j].String() + ">")
+				}
+				open = open[:i]
+				break
+			}
+
+		case html.TextToken:
+			if dropping == 0 {
+				out.WriteString(html.EscapeString(token.Data))
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i].String() + ">")
+	}
+	return out.String()
+}
+
+// Renders HTML as plain text, block elements become line breaks
+func htmlToText(raw string) string {
+	var out strings.Builder
+	dropping := 0
+	tokenizer := html.NewTokenizer(strings.NewReader(raw))
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			if droppedElements[token.DataAtom] {
+				if tokenType == html.StartTagToken && !isVoidElement(token.DataAtom) {
+					dropping++
+				} else if tokenType == html.EndTagToken && dropping > 0 {
+					dropping--
+				}
+				continue
+			}
+			if blockElements[token.DataAtom] {
+				out.WriteString("\n")
+			}
+		case html.TextToken:
+			if dropping == 0 {
+				out.WriteString(token.Data)
+			}
+		}
+	}
+
+	// Collapse whitespace within lines and drop empty lines
+	var lines []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isVoidElement(a atom.Atom) bool {
+	switch a {
+	case atom.Br, atom.Embed, atom.Hr, atom.Img, atom.Source:
+		return true
+	}
+	return false
+}
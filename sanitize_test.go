@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		base string
+		want string
+	}{
+		{"keeps allowed markup", `<p>Hello <strong>world</strong></p>`, "", `<p>Hello <strong>world</strong></p>`},
+		{"escapes text", `a &lt;b&gt; &amp; c`, "", `a &lt;b&gt; &amp; c`},
+		{"drops unknown elements but keeps their text", `<blink>hi</blink>`, "", `hi`},
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, "", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"mixed case javascript href", `<a href="JaVaScRiPt:alert(1)">x</a>`, "", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"entity encoded javascript href", `<a href="&#106;avascript&#58;alert(1)">x</a>`, "", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"javascript href with whitespace", `<a href="  javascript:alert(1)">x</a>`, "", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"javascript href with control characters", "<a href=\"java\tscript:alert(1)\">x</a>", "", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"data src", `<img src="data:image/svg+xml,<svg onload=alert(1)>">`, "", `<img>`},
+		{"keeps http href", `<a href="https://example.com/a?b=1&c=2">x</a>`, "", `<a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer">x</a>`},
+		{"mailto only on links", `<a href="mailto:a@example.com">x</a><img src="mailto:a@example.com">`, "", `<a href="mailto:a@example.com" rel="nofollow noopener noreferrer">x</a><img>`},
+		{"event handlers", `<img src="https://example.com/a.png" onerror="alert(1)" alt="a">`, "", `<img src="https://example.com/a.png" alt="a">`},
+		{"style attribute", `<p style="background:url(javascript:alert(1))" onclick="x()">a</p>`, "", `<p>a</p>`},
+		{"script", `a<script>alert("<p>")</script>b`, "", `ab`},
+		{"style element", `<style>p { color: red }</style>a`, "", `a`},
+		{"svg", `<svg><g onload="alert(1)"><a href="https://example.com">x</a></g></svg>a`, "", `a`},
+		{"math", `<math><mi>x</mi><a href="https://example.com">y</a></math>a`, "", `a`},
+		{"iframe", `<iframe src="https://example.com"><p>fallback</p></iframe>a`, "", `a`},
+		{"object and embed", `<object data="x.swf"><embed src="x.swf"></object>a`, "", `a`},
+		{"noembed", `<noembed><img src=x onerror=alert(1)></noembed>a`, "", `a`},
+		{"noscript", `<noscript><img src=x onerror=alert(1)></noscript>a`, "", `a`},
+		{"xmp", `<xmp><script>alert(1)</script></xmp>a`, "", `a`},
+		{"textarea", `<textarea></textarea><script>alert(1)</script></textarea>a`, "", `a`},
+		{"plaintext", `a<plaintext><script>alert(1)</script>`, "", `a`},
+		{"nested dropped elements", `<svg><math></math><script>x</script></svg><p>a</p>`, "", `<p>a</p>`},
+		{"relative href", `<a href="/about">x</a>`, "https://example.com/posts/1", `<a href="https://example.com/about" rel="nofollow noopener noreferrer">x</a>`},
+		{"relative src", `<img src="img/a.png">`, "https://example.com/posts/1", `<img src="https://example.com/posts/img/a.png">`},
+		{"protocol relative src", `<img src="//cdn.example.com/a.png">`, "https://example.com/", `<img src="https://cdn.example.com/a.png">`},
+		{"relative href without base", `<a href="/about">x</a>`, "", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"relative javascript does not resolve", `<a href="javascript:alert(1)">x</a>`, "https://example.com/", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"unclosed tags", `<p><em>a<strong>b`, "", `<p><em>a<strong>b</strong></em></p>`},
+		{"misnested tags", `<em><strong>a</em>b</strong>`, "", `<em><strong>a</strong></em>b`},
+		{"stray end tags", `a</p></div>b`, "", `ab`},
+		{"unclosed dropped element", `a<script>alert(1)`, "", `a`},
+		{"unclosed attribute", `<a href="https://example.com>x</a>`, "", ``},
+		{"escapes attribute values", `<img alt="&quot;><script>alert(1)</script>">`, "", `<img alt="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;">`},
+		{"namespaced attribute", `<a xlink:href="javascript:alert(1)">x</a>`, "", `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"void elements", `a<br>b<br/>c<hr>`, "", `a<br>b<br>c<hr>`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sanitizeHTML(test.raw, test.base); got != test.want {
+				t.Errorf("sanitizeHTML(%q, %q) = %q, want %q", test.raw, test.base, got, test.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`<p>Hello   <b>world</b></p><p>Again</p>`, "Hello world\nAgain"},
+		{`a<script>alert(1)</script>b`, "ab"},
+		{`a &amp; b`, "a & b"},
+		{`<noembed>x</noembed>a`, "a"},
+	}
+	for _, test := range tests {
+		if got := htmlToText(test.raw); got != test.want {
+			t.Errorf("htmlToText(%q) = %q, want %q", test.raw, got, test.want)
+		}
+	}
+}
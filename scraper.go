@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -44,6 +45,27 @@ func parseFeed(url string) (*Channel, error) {
 	return &rss.Channel, nil
 }
 
+var pubDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+}
+
+// Falls back to the current time when the date is missing or malformed
+func parsePubDate(pubDate string) time.Time {
+	pubDate = strings.TrimSpace(pubDate)
+	for _, layout := range pubDateLayouts {
+		if t, err := time.Parse(layout, pubDate); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Now().UTC()
+}
+
 func (self *apiConfig) fetch(ctx context.Context, limit int32) {
 	var wg sync.WaitGroup
 
@@ -65,19 +87,94 @@ func (self *apiConfig) fetch(ctx context.Context, limit int32) {
 				return
 			}
 
+			fullText, err := self.DB.FeedNeedsFullText(ctx, feed.ID)
+			if err != nil {
+				log.Printf("\nFeed: %s\n%s", feed.Url, err.Error())
+			}
+			articles := 0
+
 			for _, item := range rssChannel.Item {
+				postID := uuid.New()
+				author := item.Author
+				if author == "" {
+					author = item.Creator
+				}
+				content := item.Content
+				if content == "" {
+					content = item.FullText
+				}
 				if err = self.DB.CreatePost(ctx, database.CreatePostParams{
-					ID:          uuid.New(),
+					ID:          postID,
 					CreatedAt:   time.Now().UTC(),
 					UpdatedAt:   time.Now().UTC(),
 					Title:       item.Title,
 					Url:         item.Link,
 					Description: item.Description,
-					// TODO: convert this to time.Time so it's sorted correctly when selecting from database
-					PublishedAt: item.PubDate,
-					FeedID:      feed.ID,
+					PublishedAt: parsePubDate(item.PubDate),
+					FeedID:      uuid.NullUUID{UUID: feed.ID, Valid: true},
+					Author:      strings.TrimSpace(author),
+					Content:     content,
+					Guid:        strings.TrimSpace(item.GUID),
+					CommentsUrl: strings.TrimSpace(item.Comments),
 				}); err != nil {
 					log.Printf("Error creating post: %v", err.Error())
+					continue
+				}
+
+				for _, category := range item.Category {
+					if category = strings.TrimSpace(category); category == "" {
+						continue
+					}
+					if err = self.DB.CreatePostCategory(ctx, database.CreatePostCategoryParams{
+						ID:     uuid.New(),
+						PostID: postID,
+						Name:   category,
+					}); err != nil {
+						log.Printf("Error creating post category: %v", err.Error())
+					}
+				}
+
+				duration := parseITunesDuration(item.ITunesDuration)
+				for _, enclosure := range item.Enclosure {
+					if enclosure.URL == "" {
+						continue
+					}
+					params := database.CreatePostEnclosureParams{
+						ID:     uuid.New(),
+						PostID: postID,
+						Url:    enclosure.URL,
+						Type:   enclosure.Type,
+						Length: parseEnclosureLength(enclosure.Length),
+					}
+					if isMediaType(enclosure.Type) {
+						params.Duration = duration
+					}
+					if err = self.DB.CreatePostEnclosure(ctx, params); err != nil {
+						log.Printf("Error creating post enclosure: %v", err.Error())
+					}
+				}
+
+				if isPodcastEpisode(item) {
+					if err = self.DB.CreatePodcastEpisode(ctx, podcastEpisodeParams(postID, item)); err != nil {
+						log.Printf("Error creating podcast episode: %v", err.Error())
+					}
+				}
+
+				// Only new posts get here, the article is fetched once
+				if fullText && item.Link != "" && articles < maxArticlesPerFeed {
+					articles++
+					article, err := fetchArticle(ctx, item.Link)
+					if err != nil {
+						log.Printf("\nArticle: %s\n%s", item.Link, err.Error())
+						continue
+					}
+					if err = self.DB.SetPostArticle(ctx, database.SetPostArticleParams{
+						Article:   article,
+						UpdatedAt: time.Now().UTC(),
+						ID:        postID,
+					}); err != nil {
+						log.Printf("Error saving post article: %v", err.Error())
+					}
 				}
 			}
 
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"blagg/internal/database"
+)
+
+// Turns a search box query into a PostgreSQL tsquery.
+// "quoted words" match as a phrase, a trailing * matches a prefix and
+// all terms must match. Anything but letters, combining marks and digits is
+// dropped so the result is always valid tsquery syntax.
+func toTSQuery(q string) (string, error) {
+	var terms []string
+
+	words := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r)
+		})
+	}
+
+	for i, part := range strings.Split(q, `"`) {
+		// Odd parts are between quotes
+		if i%2 == 1 {
+			if phrase := words(part); len(phrase) > 0 {
+				terms = append(terms, "("+strings.Join(phrase, " <-> ")+")")
+			}
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			prefix := strings.HasSuffix(field, "*")
+			fieldWords := words(field)
+			if len(fieldWords) == 0 {
+				continue
+			}
+			if prefix {
+				fieldWords[len(fieldWords)-1] += ":*"
+			}
+			if len(fieldWords) == 1 {
+				terms = append(terms, fieldWords[0])
+			} else {
+				terms = append(terms, "("+strings.Join(fieldWords, " <-> ")+")")
+			}
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("Search query is empty")
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+type searchResult struct {
+	database.SearchPostsRow
+	DescriptionText string `json:"description_text"`
+}
+
+func (self *apiConfig) getSearchPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	query, err := toTSQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+	limit, err := limitFromQuery(r)
+	if err != nil {
+		respondWithCode(w, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	results, err := self.DB.SearchPosts(r.Context(), database.SearchPostsParams{
+		Query:      query,
+		AllFeeds:   r.URL.Query().Get("all") == "true",
+		UserID:     user.ID,
+		MaxResults: limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not search posts")
+		return
+	}
+	// The stored HTML is kept as fetched, highlights only add <mark> to it
+	response := make([]searchResult, len(results))
+	for i, result := range results {
+		descriptionText := htmlToText(result.Description)
+		result.Description = sanitizeHTML(result.Description, result.Url)
+		result.TitleHighlight = sanitizeHTML(result.TitleHighlight, result.Url)
+		result.Snippet = sanitizeHTML(result.Snippet, result.Url)
+		response[i] = searchResult{result, descriptionText}
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// Terms of letters, marks and digits, optionally prefix matched, joined by & with
+// phrases in parentheses
+var validTSQuery = regexp.MustCompile(`^(?:[\pL\pM\pN]+(:\*)?|\([\pL\pM\pN]+(:\*)?( <-> [\pL\pM\pN]+(:\*)?)+\))$`)
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"single word", "golang", "golang"},
+		{"all words must match", "go  generics", "go & generics"},
+		{"prefix", "gener*", "gener:*"},
+		{"star alone", "*", ""},
+		{"star inside a word", "ge*rics", "(ge <-> rics)"},
+		{"phrase", `"type parameters" go`, "(type <-> parameters) & go"},
+		{"unterminated quote", `go "type parameters`, "go & (type <-> parameters)"},
+		{"empty quotes", `"" go`, "go"},
+		{"quotes only", `""""`, ""},
+		{"operators", "go & !rust | (c <-> d) :* '", "go & rust & c & d"},
+		{"operators inside words", "a&b|c!d", "(a <-> b <-> c <-> d)"},
+		{"punctuation only", `!@#$%^&*()_+-=[]{};':",./<>?\|`, ""},
+		{"whitespace only", " \t\n ", ""},
+		{"empty", "", ""},
+		{"hyphenated", "e-mail", "(e <-> mail)"},
+		{"apostrophe", "don't", "(don <-> t)"},
+		{"colons", "a:b c::d", "(a <-> b) & (c <-> d)"},
+		{"backslashes", `\\a\\ \\`, "a"},
+		{"unicode letters", "café 日本語 Ελληνικά", "café & 日本語 & Ελληνικά"},
+		{"unicode digits", "١٢٣ 42", "١٢٣ & 42"},
+		{"combining marks", "cafe\u0301", "cafe\u0301"},
+		{"emoji", "go 🚀", "go"},
+		{"control characters", "go\x00lang\x7f", "(go <-> lang)"},
+		{"invalid utf-8", "go\xff\xfelang", "(go <-> lang)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toTSQuery(tt.query)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("toTSQuery(%q) = %q, want an error", tt.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("toTSQuery(%q) error: %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("toTSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+			for _, term := range regexp.MustCompile(` & `).Split(got, -1) {
+				if !validTSQuery.MatchString(term) {
+					t.Errorf("toTSQuery(%q) has invalid term %q", tt.query, term)
+				}
+			}
+		})
+	}
+}
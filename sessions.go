@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"blagg/internal/database"
+)
+
+const (
+	sessionCookie   = "session"
+	csrfCookie      = "csrf_token"
+	csrfHeader      = "X-CSRF-Token"
+	sessionDuration = 30 * 24 * time.Hour
+	minPasswordLen  = 8
+	// bcrypt ignores anything past 72 bytes
+	maxPasswordLen = 72
+)
+
+// Set by middlewareAuth to the ID of the session used for the request
+const sessionIDContextKey contextKey = "sessionID"
+
+// Compared against when the email is unknown so that the response time does
+// not tell whether an account exists
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+
+// Sessions grant the same access as an admin scoped key. Requests that change
+// anything must echo the session CSRF token in the X-CSRF-Token header.
+func (self *apiConfig) authenticateSession(w http.ResponseWriter, r *http.Request, token string, next authedHandler) {
+	row, err := self.DB.GetUserBySession(r.Context(), database.GetUserBySessionParams{
+		TokenHash: hashToken(token),
+		Now:       time.Now().UTC(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Session expired or invalid")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(csrfHeader)), []byte(row.CsrfToken)) != 1 {
+			respondWithError(w, http.StatusForbidden, "Missing or invalid CSRF token")
+			return
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), sessionIDContextKey, row.SessionID)
+	next(w, r.WithContext(ctx), row.User)
+}
+
+func setSessionCookies(w http.ResponseWriter, r *http.Request, token string, csrfToken string, expires time.Time) {
+	maxAge := 0
+	if expires.IsZero() {
+		maxAge = -1
+	}
+	secure := requestScheme(r) == "https"
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	// Readable by the browser app so it can fill in the CSRF header
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    csrfToken,
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func (self *apiConfig) putUserPassword(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Email           string `json:"email"`
+		Password        string `json:"password"`
+		CurrentPassword string `json:"current_password"`
+	}
+	type response struct {
+		Email     string    `json:"email"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+	address, err := mail.ParseAddress(params.Email)
+	if err != nil {
+		respondWithFieldError(w, "email", "Invalid email address")
+		return
+	}
+	email := strings.ToLower(address.Address)
+	if len(params.Password) < minPasswordLen || len(params.Password) > maxPasswordLen {
+		respondWithFieldError(w, "password", "Password must be between 8 and 72 bytes long")
+		return
+	}
+
+	// Changing existing credentials needs the current password
+	current, err := self.DB.GetUserCredentials(r.Context(), user.ID)
+	if err == nil {
+		if bcrypt.CompareHashAndPassword([]byte(current.PasswordHash), []byte(params.CurrentPassword)) != nil {
+			respondWithError(w, http.StatusForbidden, "Current password is incorrect")
+			return
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Could not set password")
+		return
+	}
+
+	other, err := self.DB.GetCredentialsByEmail(r.Context(), email)
+	if err == nil && other.UserID != user.ID {
+		respondWithError(w, http.StatusConflict, "Email address is already in use")
+		return
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Could not set password")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(params.Password), bcrypt.DefaultCost)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not set password")
+		return
+	}
+	credentials, err := self.DB.UpsertCredentials(r.Context(), database.UpsertCredentialsParams{
+		UserID:       user.ID,
+		CreatedAt:    time.Now().UTC(),
+		Email:        email,
+		PasswordHash: string(hash),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not set password")
+		return
+	}
+	// Sessions elsewhere may have been started by someone who knew the old
+	// password, the one making the change stays logged in
+	sessionID, _ := r.Context().Value(sessionIDContextKey).(uuid.UUID)
+	if err := self.DB.DeleteOtherUserSessions(r.Context(), database.DeleteOtherUserSessionsParams{
+		UserID: user.ID,
+		KeepID: sessionID,
+	}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not end other sessions")
+		return
+	}
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditPasswordSet,
+		TargetType: "user",
+		TargetID:   user.ID,
+		Details:    map[string]any{"email": credentials.Email},
+	})
+	respondWithJSON(w, http.StatusOK, response{credentials.Email, credentials.UpdatedAt})
+}
+
+func (self *apiConfig) postLogin(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	type response struct {
+		User      database.JSONUser `json:"user"`
+		CsrfToken string            `json:"csrf_token"`
+		ExpiresAt time.Time         `json:"expires_at"`
+	}
+
+	params := parameters{}
+	if !decodeBody(w, r, &params) {
+		return
+	}
+
+	credentials, err := self.DB.GetCredentialsByEmail(r.Context(), strings.ToLower(strings.TrimSpace(params.Email)))
+	if errors.Is(err, sql.ErrNoRows) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(params.Password))
+		respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not log in")
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(credentials.PasswordHash), []byte(params.Password)) != nil {
+		self.audit(r, credentials.UserID, auditEvent{
+			Action:     auditLoginFailed,
+			TargetType: "user",
+			TargetID:   credentials.UserID,
+		})
+		respondWithError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+	user, err := self.DB.GetUser(r.Context(), credentials.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not log in")
+		return
+	}
+	if user.DisabledAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Account is disabled")
+		return
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not log in")
+		return
+	}
+	csrfToken, err := generateToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not log in")
+		return
+	}
+	now := time.Now().UTC()
+	if err := self.DB.DeleteExpiredSessions(r.Context(), now); err != nil {
+		log.Printf("Error deleting expired sessions: %v", err.Error())
+	}
+	session, err := self.DB.CreateSession(r.Context(), database.CreateSessionParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UserID:    credentials.UserID,
+		TokenHash: hashToken(token),
+		CsrfToken: csrfToken,
+		ExpiresAt: now.Add(sessionDuration),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not log in")
+		return
+	}
+
+	self.audit(r, user.ID, auditEvent{
+		Action:     auditLogin,
+		TargetType: "session",
+		TargetID:   session.ID,
+	})
+
+	setSessionCookies(w, r, token, csrfToken, session.ExpiresAt)
+	respondWithJSON(w, http.StatusOK, response{user.Json(), csrfToken, session.ExpiresAt})
+}
+
+func (self *apiConfig) postLogout(w http.ResponseWriter, r *http.Request, user database.User) {
+	sessionID, ok := r.Context().Value(sessionIDContextKey).(uuid.UUID)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Not logged in with a session")
+		return
+	}
+	if err := self.DB.DeleteSession(r.Context(), sessionID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not log out")
+		return
+	}
+	setSessionCookies(w, r, "", "", time.Time{})
+	respondWithJSON(w, http.StatusOK, "")
+}
@@ -0,0 +1,42 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (
+  id, created_at, updated_at, user_id, name, prefix, key_hash, rotated_from, scopes
+) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9 )
+RETURNING *;
+
+-- name: GetUserByApiKey :one
+SELECT sqlc.embed(users), api_keys.id AS api_key_id, api_keys.last_used_at, api_keys.scopes
+FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.key_hash = $1 AND api_keys.revoked_at IS NULL AND users.disabled_at IS NULL
+  AND (api_keys.expires_at IS NULL OR api_keys.expires_at > @now::timestamp);
+
+-- name: GetUserApiKeys :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: TouchApiKey :exec
+UPDATE api_keys
+  SET last_used_at = $1
+  WHERE id = $2;
+
+-- name: RevokeApiKey :execrows
+UPDATE api_keys
+  SET revoked_at = $1, updated_at = $1
+  WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL;
+
+-- name: GetUserApiKey :one
+SELECT * FROM api_keys
+WHERE id = $1 AND user_id = $2;
+
+-- name: RotateApiKey :one
+UPDATE api_keys
+  SET expires_at = $1, rotated_at = $2, updated_at = $2
+  WHERE id = $3 AND user_id = $4 AND revoked_at IS NULL AND rotated_at IS NULL
+RETURNING *;
+
+-- name: RevokeUserApiKeys :exec
+UPDATE api_keys
+  SET revoked_at = $1, updated_at = $1
+  WHERE user_id = $2 AND revoked_at IS NULL;
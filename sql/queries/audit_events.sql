@@ -0,0 +1,13 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (
+  id, created_at, actor_id, subject_id, action, target_type, target_id, ip, details
+) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9 );
+
+-- name: GetAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg('user_id')::uuid IS NULL
+    OR actor_id = sqlc.narg('user_id') OR subject_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('before')::timestamp IS NULL OR created_at < sqlc.narg('before'))
+ORDER BY created_at DESC
+LIMIT @max_results;
@@ -1,13 +1,41 @@
 -- name: CreateFeedFollow :one
 INSERT INTO feed_follows(
-  id, created_at, updated_at, feed_id, user_id
-) VALUES($1, $2, $3, $4, $5)
+  id, created_at, updated_at, feed_id, user_id, title, folder_id
+) VALUES($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetUserFeedFollows :many
 SELECT * FROM feed_follows
 WHERE user_id = $1;
 
+-- name: GetUserFeedFollow :one
+SELECT * FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2;
+
+-- name: GetUserFeedFollowsInFolder :many
+SELECT * FROM feed_follows
+WHERE user_id = $1 AND folder_id = $2;
+
+-- name: UpdateFeedFollow :one
+UPDATE feed_follows
+  SET title = $1, folder_id = $2, updated_at = $3
+  WHERE id = $4 AND user_id = $5
+RETURNING *;
+
 -- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows
   WHERE id = $1;
+
+-- name: GetUserSubscriptions :many
+SELECT feed_follows.id, feed_follows.title, feeds.name AS feed_name, feeds.url AS feed_url, folders.name AS folder_name
+FROM feed_follows
+JOIN feeds ON feeds.id = feed_follows.feed_id
+LEFT JOIN folders ON folders.id = feed_follows.folder_id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name NULLS FIRST, feeds.name;
+
+-- name: SetFeedFollowFullText :one
+UPDATE feed_follows
+  SET fetch_full_text = $1, updated_at = $2
+  WHERE id = $3 AND user_id = $4
+RETURNING *;
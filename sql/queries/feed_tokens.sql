@@ -0,0 +1,18 @@
+-- name: UpsertFeedToken :one
+INSERT INTO feed_tokens (
+  user_id, created_at, token_hash
+) VALUES ( $1, $2, $3 )
+ON CONFLICT (user_id) DO UPDATE
+  SET created_at = EXCLUDED.created_at,
+      token_hash = EXCLUDED.token_hash
+RETURNING *;
+
+-- name: DeleteFeedToken :execrows
+DELETE FROM feed_tokens
+WHERE user_id = $1;
+
+-- name: GetUserByFeedToken :one
+SELECT users.* FROM feed_tokens
+JOIN users ON users.id = feed_tokens.user_id
+WHERE feed_tokens.user_id = $1 AND feed_tokens.token_hash = $2
+  AND users.disabled_at IS NULL;
@@ -16,3 +16,30 @@ LIMIT $1;
 UPDATE feeds
   SET last_fetched_at = $1, updated_at = $2
   WHERE id = $3;
+
+-- name: GetFeedByUrl :one
+SELECT * FROM feeds
+WHERE url = $1;
+
+-- name: FeedNeedsFullText :one
+SELECT EXISTS (
+  SELECT 1 FROM feed_follows
+  WHERE feed_follows.feed_id = $1 AND feed_follows.fetch_full_text
+);
+
+-- name: DeleteUnfollowedOrphanFeeds :many
+DELETE FROM feeds
+WHERE user_id IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM feed_follows
+    WHERE feed_follows.feed_id = feeds.id
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM planet_feeds
+    WHERE planet_feeds.feed_id = feeds.id
+  )
+RETURNING id, name, url;
+
+-- name: GetFeedsByIDs :many
+SELECT * FROM feeds
+WHERE id = ANY(@ids::uuid[]);
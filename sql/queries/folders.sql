@@ -0,0 +1,28 @@
+-- name: CreateFolder :one
+INSERT INTO folders(
+  id, created_at, updated_at, name, user_id
+) VALUES($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetUserFolders :many
+SELECT * FROM folders
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: GetUserFolder :one
+SELECT * FROM folders
+WHERE id = $1 AND user_id = $2;
+
+-- name: RenameFolder :one
+UPDATE folders
+  SET name = $1, updated_at = $2
+  WHERE id = $3 AND user_id = $4
+RETURNING *;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders
+  WHERE id = $1 AND user_id = $2;
+
+-- name: GetUserFolderByName :one
+SELECT * FROM folders
+WHERE user_id = $1 AND name = $2;
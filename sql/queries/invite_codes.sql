@@ -0,0 +1,25 @@
+-- name: CreateInviteCode :one
+INSERT INTO invite_codes (
+  id, created_at, created_by, code, expires_at
+) VALUES ( $1, $2, $3, $4, $5 )
+RETURNING *;
+
+-- name: GetInviteCodes :many
+SELECT * FROM invite_codes
+ORDER BY created_at DESC;
+
+-- name: DeleteInviteCode :execrows
+DELETE FROM invite_codes
+WHERE id = $1 AND used_at IS NULL;
+
+-- name: RedeemInviteCode :one
+UPDATE invite_codes
+  SET used_at = @now::timestamp
+  WHERE code = $1 AND used_at IS NULL
+    AND (expires_at IS NULL OR expires_at > @now::timestamp)
+RETURNING *;
+
+-- name: SetInviteCodeUser :exec
+UPDATE invite_codes
+  SET used_by = $1
+  WHERE id = $2;
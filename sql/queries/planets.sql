@@ -0,0 +1,47 @@
+-- name: CreatePlanet :one
+INSERT INTO planets (
+  id, created_at, updated_at, slug, title, description
+) VALUES ( $1, $2, $3, $4, $5, $6 )
+RETURNING *;
+
+-- name: GetPlanets :many
+SELECT * FROM planets
+ORDER BY title;
+
+-- name: GetPlanet :one
+SELECT * FROM planets
+WHERE id = $1;
+
+-- name: GetPlanetBySlug :one
+SELECT * FROM planets
+WHERE slug = $1;
+
+-- name: DeletePlanet :execrows
+DELETE FROM planets
+WHERE id = $1;
+
+-- name: AddPlanetFeed :exec
+INSERT INTO planet_feeds (
+  planet_id, feed_id, created_at
+) VALUES ( $1, $2, $3 )
+ON CONFLICT (planet_id, feed_id) DO NOTHING;
+
+-- name: RemovePlanetFeed :execrows
+DELETE FROM planet_feeds
+WHERE planet_id = $1 AND feed_id = $2;
+
+-- name: GetPlanetFeeds :many
+SELECT feeds.*, count(posts.id) AS post_count
+FROM planet_feeds
+JOIN feeds ON feeds.id = planet_feeds.feed_id
+LEFT JOIN posts ON posts.feed_id = feeds.id
+WHERE planet_feeds.planet_id = $1
+GROUP BY feeds.id
+ORDER BY feeds.name;
+
+-- name: GetPlanetPosts :many
+SELECT posts.* FROM posts
+JOIN planet_feeds ON planet_feeds.feed_id = posts.feed_id
+WHERE planet_feeds.planet_id = $1
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $2;
@@ -0,0 +1,19 @@
+-- name: CreatePodcastEpisode :exec
+INSERT INTO podcast_episodes (
+  id, post_id, episode, image_url, transcript_url, transcript_type
+) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (post_id) DO NOTHING;
+
+-- name: GetUserPodcastEpisodes :many
+SELECT posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id,
+  podcast_episodes.episode, podcast_episodes.image_url,
+  podcast_episodes.transcript_url, podcast_episodes.transcript_type,
+  post_states.playback_position, post_states.read_at
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN podcast_episodes ON podcast_episodes.post_id = posts.id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $2;
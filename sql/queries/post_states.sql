@@ -0,0 +1,53 @@
+-- name: SetPostsReadAt :execrows
+INSERT INTO post_states (
+  id, created_at, updated_at, user_id, post_id, read_at
+)
+SELECT gen_random_uuid(), @updated_at::timestamp, @updated_at::timestamp, feed_follows.user_id, posts.id, sqlc.narg('read_at')::timestamp
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+  AND (sqlc.narg('post_ids')::uuid[] IS NULL OR posts.id = ANY(sqlc.narg('post_ids')::uuid[]))
+  AND (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id'))
+  AND (sqlc.narg('folder_id')::uuid IS NULL OR feed_follows.folder_id = sqlc.narg('folder_id'))
+  AND (sqlc.narg('older_than')::timestamp IS NULL OR posts.published_at < sqlc.narg('older_than'))
+ON CONFLICT (user_id, post_id) DO UPDATE
+  SET read_at = EXCLUDED.read_at, updated_at = EXCLUDED.updated_at;
+
+-- name: GetUserUnreadCounts :many
+SELECT posts.feed_id, COUNT(*) AS unread
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_states ON post_states.post_id = posts.id
+  AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND post_states.read_at IS NULL
+GROUP BY posts.feed_id;
+
+-- name: StarPost :execrows
+INSERT INTO post_states (
+  id, created_at, updated_at, user_id, post_id, starred_at
+)
+SELECT gen_random_uuid(), @updated_at::timestamp, @updated_at::timestamp, feed_follows.user_id, posts.id, @updated_at::timestamp
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id AND posts.id = @post_id
+ON CONFLICT (user_id, post_id) DO UPDATE
+  SET starred_at = COALESCE(post_states.starred_at, EXCLUDED.starred_at),
+    updated_at = EXCLUDED.updated_at;
+
+-- name: UnstarPost :execrows
+UPDATE post_states
+  SET starred_at = NULL, updated_at = $1
+  WHERE user_id = $2 AND post_id = $3 AND starred_at IS NOT NULL;
+
+-- name: SetPlaybackPosition :execrows
+INSERT INTO post_states (
+  id, created_at, updated_at, user_id, post_id, playback_position
+)
+SELECT gen_random_uuid(), @updated_at::timestamp, @updated_at::timestamp, feed_follows.user_id, posts.id, @playback_position::integer
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN podcast_episodes ON podcast_episodes.post_id = posts.id
+WHERE feed_follows.user_id = @user_id AND posts.id = @post_id
+ON CONFLICT (user_id, post_id) DO UPDATE
+  SET playback_position = EXCLUDED.playback_position,
+    updated_at = EXCLUDED.updated_at;
@@ -1,13 +1,70 @@
 -- name: CreatePost :exec
 INSERT INTO posts (
-  id, created_at, updated_at, title, url, description, published_at, feed_id
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
-
--- name: GetPostByUser :many
-SELECT * FROM posts
-WHERE posts.feed_id in (
-  select feed_follows.feed_id from feed_follows
-  where feed_follows.user_id = $1
-)
-ORDER BY posts.published_at DESC
-LIMIT $2;
+  id, created_at, updated_at, title, url, description, published_at, feed_id, author,
+  content, guid, comments_url
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- name: CreatePostCategory :exec
+INSERT INTO post_categories (
+  id, post_id, name
+) VALUES ($1, $2, $3)
+ON CONFLICT (post_id, name) DO NOTHING;
+
+-- name: GetPostsCategories :many
+SELECT * FROM post_categories
+WHERE post_id = ANY(@post_ids::uuid[])
+ORDER BY name;
+
+-- name: CreatePostEnclosure :exec
+INSERT INTO post_enclosures (
+  id, post_id, url, type, length, duration
+) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (post_id, url) DO NOTHING;
+
+-- name: GetPostsEnclosures :many
+SELECT * FROM post_enclosures
+WHERE post_id = ANY(@post_ids::uuid[]);
+
+-- name: SearchPosts :many
+SELECT ranked.id, ranked.created_at, ranked.updated_at, ranked.title, ranked.url,
+  ranked.description, ranked.published_at, ranked.feed_id, ranked.author, ranked.rank,
+  ts_headline('english', ranked.title, to_tsquery('english', @query),
+    'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS title_highlight,
+  ts_headline('english', ranked.description || ' ' || ranked.content, to_tsquery('english', @query),
+    'MaxFragments=2, MaxWords=30, MinWords=10, StartSel=<mark>, StopSel=</mark>')::text AS snippet
+FROM (
+  SELECT posts.*, ts_rank(posts.search, to_tsquery('english', @query)) AS rank
+  FROM posts
+  WHERE posts.search @@ to_tsquery('english', @query)
+    AND (@all_feeds::bool OR posts.feed_id IN (
+      select feed_follows.feed_id from feed_follows
+      where feed_follows.user_id = @user_id
+    ))
+  ORDER BY rank DESC, posts.published_at DESC
+  LIMIT @max_results
+) AS ranked
+ORDER BY ranked.rank DESC, ranked.published_at DESC;
+
+-- name: SetPostArticle :exec
+UPDATE posts
+  SET article = $1, updated_at = $2
+  WHERE id = $3;
+
+-- name: DeleteUnstarredOrphanPost :exec
+DELETE FROM posts
+WHERE posts.id = $1
+  AND feed_id IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.starred_at IS NOT NULL
+  );
+
+-- name: DeleteUnstarredOrphanPosts :exec
+DELETE FROM posts
+WHERE feed_id IS NULL
+  AND NOT EXISTS (
+    SELECT 1 FROM post_states
+    WHERE post_states.post_id = posts.id
+      AND post_states.starred_at IS NOT NULL
+  );
@@ -0,0 +1,46 @@
+-- name: UpsertCredentials :one
+INSERT INTO credentials (
+  user_id, created_at, updated_at, email, password_hash
+) VALUES ( $1, $2, $2, $3, $4 )
+ON CONFLICT (user_id) DO UPDATE
+  SET updated_at = EXCLUDED.updated_at,
+      email = EXCLUDED.email,
+      password_hash = EXCLUDED.password_hash
+RETURNING *;
+
+-- name: GetUserCredentials :one
+SELECT * FROM credentials
+WHERE user_id = $1;
+
+-- name: GetCredentialsByEmail :one
+SELECT * FROM credentials
+WHERE email = $1;
+
+-- name: CreateSession :one
+INSERT INTO sessions (
+  id, created_at, user_id, token_hash, csrf_token, expires_at
+) VALUES ( $1, $2, $3, $4, $5, $6 )
+RETURNING *;
+
+-- name: GetUserBySession :one
+SELECT sqlc.embed(users), sessions.id AS session_id, sessions.csrf_token
+FROM sessions
+JOIN users ON users.id = sessions.user_id
+WHERE sessions.token_hash = $1 AND sessions.expires_at > @now::timestamp
+  AND users.disabled_at IS NULL;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions
+WHERE id = $1;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions
+WHERE expires_at <= @now::timestamp;
+
+-- name: DeleteUserSessions :exec
+DELETE FROM sessions
+WHERE user_id = $1;
+
+-- name: DeleteOtherUserSessions :exec
+DELETE FROM sessions
+WHERE user_id = $1 AND id <> @keep_id;
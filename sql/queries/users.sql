@@ -1,9 +1,39 @@
 -- name: CreateUser :one
 INSERT INTO users (
-  id, created_at, updated_at, name, api_key
-) VALUES ( $1, $2, $3, $4, encode(sha256(random()::text::bytea), 'hex'))
+  id, created_at, updated_at, name, role
+) VALUES ( $1, $2, $3, $4, $5 )
 RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE api_key = $1;
+WHERE id = $1;
+
+-- name: CountUsers :one
+SELECT count(*) FROM users;
+
+-- name: LockUsers :exec
+LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE;
+
+-- name: GetAllUsers :many
+SELECT * FROM users
+ORDER BY created_at;
+
+-- name: UpdateUserAdmin :one
+UPDATE users
+  SET role = $1, disabled_at = $2, updated_at = $3
+  WHERE id = $4
+RETURNING *;
+
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id = $1;
+
+-- name: UpdateUserName :one
+UPDATE users
+  SET name = $1, updated_at = $2
+  WHERE id = $3
+RETURNING *;
+
+-- name: CountOtherActiveAdmins :one
+SELECT count(*) FROM users
+WHERE role = 'admin' AND disabled_at IS NULL AND id <> $1;
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE folders (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  name TEXT NOT NULL,
+  user_id UUID NOT NULL,
+  FOREIGN KEY(user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+  UNIQUE (user_id, name)
+);
+
+ALTER TABLE feed_follows
+ADD title TEXT DEFAULT NULL,
+ADD folder_id UUID DEFAULT NULL
+  REFERENCES folders(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN folder_id,
+DROP COLUMN title;
+
+DROP TABLE folders;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION parse_published_at(value TEXT, fallback TIMESTAMP) RETURNS TIMESTAMP AS $$
+BEGIN
+  RETURN value::timestamptz AT TIME ZONE 'UTC';
+EXCEPTION WHEN others THEN
+  RETURN fallback;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+ALTER TABLE posts
+ALTER COLUMN published_at TYPE TIMESTAMP
+USING parse_published_at(published_at, created_at);
+
+DROP FUNCTION parse_published_at;
+
+-- +goose Down
+ALTER TABLE posts
+ALTER COLUMN published_at TYPE TEXT
+USING to_char(published_at, 'Dy, DD Mon YYYY HH24:MI:SS "+0000"');
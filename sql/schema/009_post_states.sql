@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE post_states (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL,
+  post_id UUID NOT NULL,
+  read_at TIMESTAMP DEFAULT NULL,
+  FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE,
+  UNIQUE (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE post_states;
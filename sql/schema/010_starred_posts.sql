@@ -0,0 +1,41 @@
+-- +goose Up
+ALTER TABLE post_states
+ADD starred_at TIMESTAMP DEFAULT NULL;
+
+-- Starred posts outlive their feed, the others are removed with it
+ALTER TABLE posts
+ALTER COLUMN feed_id DROP NOT NULL,
+DROP CONSTRAINT posts_feed_id_fkey,
+ADD FOREIGN KEY(feed_id) REFERENCES feeds(id) ON DELETE SET NULL;
+
+-- +goose StatementBegin
+CREATE FUNCTION delete_unstarred_feed_posts() RETURNS trigger AS $$
+BEGIN
+  DELETE FROM posts
+  WHERE posts.feed_id = OLD.id
+    AND NOT EXISTS (
+      SELECT 1 FROM post_states
+      WHERE post_states.post_id = posts.id
+        AND post_states.starred_at IS NOT NULL
+    );
+  RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER feeds_delete_unstarred_posts
+BEFORE DELETE ON feeds
+FOR EACH ROW EXECUTE FUNCTION delete_unstarred_feed_posts();
+
+-- +goose Down
+DROP TRIGGER feeds_delete_unstarred_posts ON feeds;
+DROP FUNCTION delete_unstarred_feed_posts;
+
+DELETE FROM posts WHERE feed_id IS NULL;
+ALTER TABLE posts
+DROP CONSTRAINT posts_feed_id_fkey,
+ADD FOREIGN KEY(feed_id) REFERENCES feeds(id) ON DELETE CASCADE,
+ALTER COLUMN feed_id SET NOT NULL;
+
+ALTER TABLE post_states
+DROP COLUMN starred_at;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE posts
+ADD author TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE post_categories (
+  id UUID PRIMARY KEY,
+  post_id UUID NOT NULL,
+  name TEXT NOT NULL,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE,
+  UNIQUE (post_id, name)
+);
+
+CREATE INDEX post_categories_name_idx ON post_categories (lower(name));
+
+-- +goose Down
+DROP TABLE post_categories;
+
+ALTER TABLE posts
+DROP COLUMN author;
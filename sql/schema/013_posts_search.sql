@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE posts
+ADD content TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE posts
+ADD search TSVECTOR GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', title), 'A') ||
+  setweight(to_tsvector('english', description), 'B') ||
+  setweight(to_tsvector('english', content), 'C')
+) STORED;
+
+CREATE INDEX posts_search_idx ON posts USING GIN (search);
+
+-- +goose Down
+DROP INDEX posts_search_idx;
+
+ALTER TABLE posts
+DROP COLUMN search,
+DROP COLUMN content;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE posts
+ADD guid TEXT NOT NULL DEFAULT '',
+ADD comments_url TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE post_enclosures (
+  id UUID PRIMARY KEY,
+  post_id UUID NOT NULL,
+  url TEXT NOT NULL,
+  type TEXT NOT NULL,
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE,
+  UNIQUE (post_id, url)
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
+
+ALTER TABLE posts
+DROP COLUMN comments_url,
+DROP COLUMN guid;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE post_enclosures
+ADD length BIGINT DEFAULT NULL,
+ADD duration INTEGER DEFAULT NULL;
+
+CREATE TABLE podcast_episodes (
+  id UUID PRIMARY KEY,
+  post_id UUID NOT NULL UNIQUE,
+  episode INTEGER DEFAULT NULL,
+  image_url TEXT NOT NULL DEFAULT '',
+  transcript_url TEXT NOT NULL DEFAULT '',
+  transcript_type TEXT NOT NULL DEFAULT '',
+  FOREIGN KEY(post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+ALTER TABLE post_states
+ADD playback_position INTEGER DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE post_states
+DROP COLUMN playback_position;
+
+DROP TABLE podcast_episodes;
+
+ALTER TABLE post_enclosures
+DROP COLUMN duration,
+DROP COLUMN length;
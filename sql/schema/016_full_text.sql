@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feed_follows
+ADD fetch_full_text BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE posts
+ADD article TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN article;
+
+ALTER TABLE feed_follows
+DROP COLUMN fetch_full_text;
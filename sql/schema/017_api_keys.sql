@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE api_keys (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL,
+  name TEXT NOT NULL,
+  prefix TEXT NOT NULL,
+  key_hash VARCHAR(64) NOT NULL UNIQUE,
+  last_used_at TIMESTAMP DEFAULT NULL,
+  revoked_at TIMESTAMP DEFAULT NULL,
+  FOREIGN KEY(user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+-- Existing keys keep working, only their hash is stored from now on
+INSERT INTO api_keys (
+  id, created_at, updated_at, user_id, name, prefix, key_hash
+)
+SELECT gen_random_uuid(), now() AT TIME ZONE 'UTC', now() AT TIME ZONE 'UTC',
+  id, 'default', left(api_key, 8), encode(sha256(api_key::bytea), 'hex')
+FROM users;
+
+ALTER TABLE users
+DROP COLUMN api_key;
+
+-- +goose Down
+ALTER TABLE users
+ADD api_key VARCHAR(64) NOT NULL UNIQUE
+DEFAULT encode(sha256(random()::text::bytea), 'hex');
+
+DROP TABLE api_keys;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE api_keys
+ADD expires_at TIMESTAMP DEFAULT NULL,
+ADD rotated_at TIMESTAMP DEFAULT NULL,
+ADD rotated_from UUID DEFAULT NULL
+  REFERENCES api_keys(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE api_keys
+DROP COLUMN rotated_from,
+DROP COLUMN rotated_at,
+DROP COLUMN expires_at;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE users
+ADD role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+ADD disabled_at TIMESTAMP DEFAULT NULL;
+
+-- The oldest account administers existing installs
+UPDATE users SET role = 'admin'
+WHERE id = (SELECT id FROM users ORDER BY created_at LIMIT 1);
+
+CREATE TABLE invite_codes (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  created_by UUID DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  code TEXT NOT NULL UNIQUE,
+  expires_at TIMESTAMP DEFAULT NULL,
+  used_at TIMESTAMP DEFAULT NULL,
+  used_by UUID DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- +goose Down
+DROP TABLE invite_codes;
+
+ALTER TABLE users
+DROP COLUMN disabled_at,
+DROP COLUMN role;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Deleting a user removes their follows, feeds they added stay for the
+-- other followers
+ALTER TABLE feed_follows
+DROP CONSTRAINT feed_follows_user_id_fkey,
+ADD FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE feeds
+ALTER COLUMN user_id DROP NOT NULL,
+DROP CONSTRAINT feeds_user_id_fkey,
+ADD FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+DELETE FROM feeds WHERE user_id IS NULL;
+ALTER TABLE feeds
+DROP CONSTRAINT feeds_user_id_fkey,
+ADD FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE feed_follows
+DROP CONSTRAINT feed_follows_user_id_fkey,
+ADD FOREIGN KEY(user_id) REFERENCES users(id);
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Events keep the IDs of deleted users and objects so there are no foreign keys
+CREATE TABLE audit_events (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  actor_id UUID NOT NULL,
+  subject_id UUID NOT NULL,
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  target_id UUID DEFAULT NULL,
+  ip TEXT NOT NULL,
+  details JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_events_actor_idx ON audit_events (actor_id, created_at DESC);
+CREATE INDEX audit_events_subject_idx ON audit_events (subject_id, created_at DESC);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION reject_audit_event_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_append_only
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION reject_audit_event_change();
+
+-- +goose Down
+DROP TRIGGER audit_events_append_only ON audit_events;
+DROP FUNCTION reject_audit_event_change;
+DROP TABLE audit_events;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE feed_tokens (
+  user_id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  token_hash VARCHAR(64) NOT NULL UNIQUE,
+  FOREIGN KEY(user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE feed_tokens;
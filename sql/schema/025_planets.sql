@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE planets (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  slug TEXT NOT NULL UNIQUE,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE planet_feeds (
+  planet_id UUID NOT NULL REFERENCES planets(id) ON DELETE CASCADE,
+  feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (planet_id, feed_id)
+);
+
+-- +goose Down
+DROP TABLE planet_feeds;
+DROP TABLE planets;
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxBodySize          = 1 << 20
+	maxNameLength        = 200
+	maxURLLength         = 2048
+	maxInviteLength      = 64
+	maxSlugLength        = 64
+	maxDescriptionLength = 2000
+)
+
+// Decodes a JSON request body into params. Bodies over maxBodySize, unknown
+// fields and trailing data are rejected and answered here, in which case
+// false is returned.
+func decodeBody(w http.ResponseWriter, r *http.Request, params any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(params)
+	if err == nil && decoder.More() {
+		err = errors.New("trailing data after JSON body")
+	}
+	if err == nil {
+		return true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		respondWithFieldError(w, typeErr.Field, "Expected a value of type "+typeErr.Type.String())
+		return false
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		respondWithFieldError(w, strings.Trim(field, `"`), "Unknown field")
+		return false
+	}
+	respondWithDecodeError(w, err)
+	return false
+}
+
+// Collects field errors so a client can fix them all in one go
+type validator struct {
+	errors []fieldError
+}
+
+func (self *validator) check(ok bool, field string, msg string) {
+	if !ok {
+		self.errors = append(self.errors, fieldError{field, msg})
+	}
+}
+
+func (self *validator) required(field string, value string) {
+	self.check(strings.TrimSpace(value) != "", field, "Required")
+}
+
+func (self *validator) maxLength(field string, value string, max int) {
+	self.check(utf8.RuneCountInString(value) <= max, field, fmt.Sprintf("Must be at most %d characters", max))
+}
+
+// Only absolute http and https URLs are fetched, empty values are left to required
+func (self *validator) url(field string, value string) {
+	if value == "" {
+		return
+	}
+	if len(value) > maxURLLength {
+		self.check(false, field, fmt.Sprintf("Must be at most %d characters", maxURLLength))
+		return
+	}
+	u, err := url.Parse(value)
+	self.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", field, "Must be an http or https URL")
+}
+
+// IDs are decoded as strings so a malformed one is reported on its field,
+// empty values are left to required
+func (self *validator) id(field string, value string) uuid.UUID {
+	if value == "" {
+		return uuid.Nil
+	}
+	id, err := uuid.Parse(value)
+	self.check(err == nil, field, "Must be a UUID")
+	return id
+}
+
+// Like id for fields that may be left out
+func (self *validator) optionalID(field string, value *string) *uuid.UUID {
+	if value == nil {
+		return nil
+	}
+	id, err := uuid.Parse(*value)
+	if err != nil {
+		self.check(false, field, "Must be a UUID")
+		return nil
+	}
+	return &id
+}
+
+// Answers 422 with every collected error, returns false when there were none
+func (self *validator) failed(w http.ResponseWriter) bool {
+	if len(self.errors) == 0 {
+		return false
+	}
+	respondWithFieldErrors(w, self.errors)
+	return true
+}